@@ -1,8 +1,13 @@
 package common
 
 import (
+	"bytes"
 	"os"
 	"os/exec"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh/terminal"
 
 	"github.com/alphatr/acme-lego/common/errors"
 )
@@ -24,6 +29,52 @@ func RunCommand(command string) (string, *errors.Error) {
 	return string(output), nil
 }
 
+// RunCommandEnv 带环境变量和超时执行命令, args 转义后追加到命令后面
+func RunCommandEnv(command string, env []string, timeout time.Duration, args ...string) (string, *errors.Error) {
+	line := command
+	for _, arg := range args {
+		line += " " + shellQuote(arg)
+	}
+
+	output := &bytes.Buffer{}
+	cmd := NewBash(line)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	setProcessGroup(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return "", errors.NewError(errors.CommonCommandRunErrno, err, command)
+	}
+
+	// 超时后结束整个进程组, 避免子进程占用输出导致一直等待
+	expired := make(chan bool, 1)
+	timer := time.AfterFunc(timeout, func() {
+		expired <- true
+		killProcessGroup(cmd)
+	})
+
+	err := cmd.Wait()
+	timer.Stop()
+
+	select {
+	case <-expired:
+		return output.String(), errors.NewError(errors.CommonCommandTimeoutErrno, err, command)
+	default:
+	}
+
+	if err != nil {
+		return output.String(), errors.NewError(errors.CommonCommandRunErrno, err, command)
+	}
+
+	return output.String(), nil
+}
+
+// shellQuote 使用单引号转义参数
+func shellQuote(arg string) string {
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+}
+
 // RunCommandBindTerminal 在终端中执行命令
 func RunCommandBindTerminal(command string) {
 	cmd := NewBash(command)
@@ -33,3 +84,8 @@ func RunCommandBindTerminal(command string) {
 	cmd.Stderr = os.Stderr
 	cmd.Run()
 }
+
+// StdinIsTerminal 标准输入是否为终端
+func StdinIsTerminal() bool {
+	return terminal.IsTerminal(int(os.Stdin.Fd()))
+}
@@ -0,0 +1,18 @@
+// +build !windows
+
+package common
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup 命令在新的进程组中执行
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup 结束命令所在的进程组
+func killProcessGroup(cmd *exec.Cmd) {
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
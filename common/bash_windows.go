@@ -0,0 +1,13 @@
+package common
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup Windows 下不需要处理
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup 结束命令进程
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.Process.Kill()
+}
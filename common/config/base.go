@@ -1,7 +1,11 @@
 package config
 
 import (
+	"fmt"
+	"net"
+	"net/url"
 	"path"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -11,7 +15,20 @@ import (
 	"github.com/alphatr/acme-lego/common/errors"
 )
 
-const defaultAcmeURL = "https://acme-v02.api.letsencrypt.org/directory"
+const (
+	defaultAcmeURL       = "https://acme-v02.api.letsencrypt.org/directory"
+	defaultRenewInterval = "12h"
+	defaultRenewJitter   = "1h"
+)
+
+// acmeURLShortcuts 常用 CA 的目录地址
+var acmeURLShortcuts = map[string]string{
+	"letsencrypt":         defaultAcmeURL,
+	"letsencrypt-staging": "https://acme-staging-v02.api.letsencrypt.org/directory",
+	"zerossl":             "https://acme.zerossl.com/v2/DV90",
+	"buypass":             "https://api.buypass.com/acme/directory",
+	"buypass-staging":     "https://api.test4.buypass.no/acme/directory",
+}
 
 // BaseConf 配置
 type BaseConf struct {
@@ -26,31 +43,90 @@ type BaseConf struct {
 	RootDir     string
 	Expires     time.Duration
 	AfterRenew  string
+
+	RenewInterval time.Duration
+	RenewJitter   time.Duration
+
+	InsecureSkipVerify bool
+	CABundle           string
+
+	EABKid     string
+	EABHmacKey string
+
+	DNS  DNSConf
+	File FileConf
 }
 
 // Config 配置
 var Config BaseConf
 
 func initBaseConfig(conf *baseTOML, configPath string) *errors.Error {
-	Config.Name = "alphatr-lego"
-	Config.Dev = conf.Dev
-	Config.LogLevel = conf.LogLevel
-	Config.Email = conf.Email
-	Config.HTTPTimeout = 30
-	Config.Expires = time.Duration(conf.ExpireDays) * time.Hour * 24
-	Config.AfterRenew = conf.AfterRenew
-	Config.RootDir = common.DefaultString(conf.RootDir, path.Dir(configPath))
+	list := &problemList{tree: conf.tree}
+	if validateBase(conf, list); list.firstError() != nil {
+		return errors.NewError(errors.ConfigValidateErrno, nil, list.firstError())
+	}
+
+	result := BaseConf{
+		Name:        "alphatr-lego",
+		Dev:         conf.Dev,
+		LogLevel:    conf.LogLevel,
+		Email:       conf.Email,
+		HTTPTimeout: 30,
+		Expires:     time.Duration(conf.ExpireDays) * time.Hour * 24,
+		AfterRenew:  conf.AfterRenew,
+		RootDir:     common.DefaultString(conf.RootDir, path.Dir(configPath)),
+		EABKid:      conf.EABKid,
+		EABHmacKey:  conf.EABHmacKey,
+	}
+
+	acmeURL, err := ResolveAcmeURL(conf.AcmeURL)
+	if err != nil {
+		return errors.NewError(errors.ConfigAcmeURLErrno, err, conf.AcmeURL)
+	}
+
+	result.AcmeURL = acmeURL
+
+	// 开发模式一般使用 pebble 等测试服务, 保持忽略证书校验
+	result.InsecureSkipVerify = conf.InsecureSkipVerify || conf.Dev
+	if len(conf.CABundle) > 0 {
+		result.CABundle = conf.CABundle
+		if !filepath.IsAbs(conf.CABundle) {
+			result.CABundle = filepath.Join(filepath.Dir(configPath), conf.CABundle)
+		}
+	}
 
-	Config.AcmeURL = defaultAcmeURL
-	if conf.Dev {
-		Config.AcmeURL = common.DefaultString(conf.AcmeURL, defaultAcmeURL)
+	interval, errs := ParseDuration(common.DefaultString(conf.RenewInterval, defaultRenewInterval), false)
+	if errs != nil {
+		return errors.NewError(errors.ConfigParseDurationErrno, errs, "renew-interval")
 	}
 
+	jitter, errs := ParseDuration(common.DefaultString(conf.RenewJitter, defaultRenewJitter), true)
+	if errs != nil {
+		return errors.NewError(errors.ConfigParseDurationErrno, errs, "renew-jitter")
+	}
+
+	result.RenewInterval = interval
+	result.RenewJitter = jitter
+
+	dns, err := initDNSConfig(DNSConf{DisableCP: conf.DisableCP}, conf.DNSResolvers, conf.PropagationTimeout, conf.PollingInterval, conf.TTL)
+	if err != nil {
+		return err
+	}
+
+	result.DNS = dns
+
+	defaultFile := FileConf{UID: -1, GID: -1}
+	file, err := initFileConfig(defaultFile, conf.Owner, conf.Group, common.DefaultString(conf.FileMode, defaultFileMode), common.DefaultString(conf.DirMode, defaultDirMode))
+	if err != nil {
+		return err
+	}
+
+	result.File = file
 	types := KeyTypeList(conf.KeyType)
 
 	domainGroup := map[string]*DomainConf{}
 	for domain, value := range conf.DomainGroup {
-		conf, err := initDomainConfig(domain, &value, types, conf)
+		conf, err := initDomainConfig(domain, &value, types, conf, dns, file)
 		if err != nil {
 			return errors.NewError(errors.ConfigDomainInitErrno, err)
 		}
@@ -58,10 +134,35 @@ func initBaseConfig(conf *baseTOML, configPath string) *errors.Error {
 		domainGroup[strings.ToLower(domain)] = conf
 	}
 
-	Config.DomainGroup = domainGroup
+	result.DomainGroup = domainGroup
+
+	// 解析全部成功后再替换, 重新加载配置失败时保留原配置
+	Config = result
 	return nil
 }
 
+// ResolveAcmeURL 解析 ACME 目录地址, 支持常用 CA 的简写
+func ResolveAcmeURL(input string) (string, *errors.Error) {
+	if len(input) == 0 {
+		return defaultAcmeURL, nil
+	}
+
+	if result, ok := acmeURLShortcuts[strings.ToLower(input)]; ok {
+		return result, nil
+	}
+
+	parsed, err := url.Parse(input)
+	if err != nil {
+		return "", errors.NewError(errors.CommonParseURLErrno, err, input)
+	}
+
+	if (parsed.Scheme != "https" && parsed.Scheme != "http") || len(parsed.Host) == 0 {
+		return "", errors.NewError(errors.CommonParseURLErrno, nil, input)
+	}
+
+	return input, nil
+}
+
 // KeyTypeList 返回证书类型列表
 func KeyTypeList(input []string) []certcrypto.KeyType {
 	getKeyType := func(input string) (certcrypto.KeyType, bool) {
@@ -90,3 +191,22 @@ func KeyTypeList(input []string) []certcrypto.KeyType {
 
 	return result
 }
+
+// ParseDuration 解析配置中的时间间隔, 只有 zero 为 true 时允许为 0, 配置加载和 check-config 共用
+func ParseDuration(input string, zero bool) (time.Duration, error) {
+	duration, err := time.ParseDuration(input)
+	if err != nil {
+		return 0, err
+	}
+
+	if duration < 0 || (duration == 0 && !zero) {
+		return 0, fmt.Errorf("duration %q must be positive", input)
+	}
+
+	return duration, nil
+}
+
+// ParseServer 解析 host:port 格式的监听地址, 端口为空时由验证方式使用默认端口
+func ParseServer(input string) (string, string, error) {
+	return net.SplitHostPort(input)
+}
@@ -15,6 +15,35 @@ type domainTOML struct {
 	KeyType   []string          `toml:"key-type"`
 	Challenge string            `toml:"challenge"`
 	Options   map[string]string `toml:"options"`
+
+	Challenges        map[string]string `toml:"challenges"`
+	WildcardChallenge string            `toml:"wildcard-challenge"`
+
+	AcmeURL    string `toml:"acme-url"`
+	Email      string `toml:"email"`
+	EABKid     string `toml:"eab-kid"`
+	EABHmacKey string `toml:"eab-hmac-key"`
+
+	DNSResolvers       []string `toml:"dns-resolvers"`
+	PropagationTimeout string   `toml:"propagation-timeout"`
+	PollingInterval    string   `toml:"polling-interval"`
+	TTL                int      `toml:"ttl"`
+	DisableCP          *bool    `toml:"disable-cp"`
+
+	SelfCheck  *bool `toml:"self-check"`
+	MustStaple *bool `toml:"must-staple"`
+
+	PreHook    string `toml:"pre-hook"`
+	PostHook   string `toml:"post-hook"`
+	DeployHook string `toml:"deploy-hook"`
+
+	Outputs []outputTOML `toml:"outputs"`
+
+	Owner    string       `toml:"owner"`
+	Group    string       `toml:"group"`
+	FileMode string       `toml:"file-mode"`
+	DirMode  string       `toml:"dir-mode"`
+	DeployTo []deployTOML `toml:"deploy-to"`
 }
 
 type baseTOML struct {
@@ -28,6 +57,39 @@ type baseTOML struct {
 	DomainGroup map[string]domainTOML `toml:"domain-group"`
 	ExpireDays  int                   `toml:"expire-days"`
 	AfterRenew  string                `toml:"after-renew"`
+
+	RenewInterval string `toml:"renew-interval"`
+	RenewJitter   string `toml:"renew-jitter"`
+
+	InsecureSkipVerify bool   `toml:"insecure-skip-verify"`
+	CABundle           string `toml:"ca-bundle"`
+
+	EABKid     string `toml:"eab-kid"`
+	EABHmacKey string `toml:"eab-hmac-key"`
+
+	WildcardChallenge string `toml:"wildcard-challenge"`
+
+	DNSResolvers       []string `toml:"dns-resolvers"`
+	PropagationTimeout string   `toml:"propagation-timeout"`
+	PollingInterval    string   `toml:"polling-interval"`
+	TTL                int      `toml:"ttl"`
+	DisableCP          bool     `toml:"disable-cp"`
+
+	SelfCheck  bool `toml:"self-check"`
+	MustStaple bool `toml:"must-staple"`
+
+	PreHook    string `toml:"pre-hook"`
+	PostHook   string `toml:"post-hook"`
+	DeployHook string `toml:"deploy-hook"`
+
+	Outputs []outputTOML `toml:"outputs"`
+
+	Owner    string `toml:"owner"`
+	Group    string `toml:"group"`
+	FileMode string `toml:"file-mode"`
+	DirMode  string `toml:"dir-mode"`
+
+	tree *toml.Tree
 }
 
 // InitConfig 配置初始化
@@ -59,10 +121,17 @@ func parseTOML(configPath string) (*baseTOML, *errors.Error) {
 		return nil, errors.NewError(errors.CommonFileReadErrno, err, configPath)
 	}
 
+	tree, err := toml.LoadBytes(configBytes)
+	if err != nil {
+		return nil, errors.NewError(errors.CommonTOMLUnmarshalErrno, err)
+	}
+
 	var conf baseTOML
-	if err := toml.Unmarshal(configBytes, &conf); err != nil {
+	if err := tree.Unmarshal(&conf); err != nil {
 		return nil, errors.NewError(errors.CommonTOMLUnmarshalErrno, err)
 	}
 
+	// 保留 TOML 树, 用于定位配置问题所在的行
+	conf.tree = tree
 	return &conf, nil
 }
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"time"
+
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+// DNSConf DNS 验证的配置, 为空时使用 lego 和 Provider 的默认值
+type DNSConf struct {
+	Resolvers          []string
+	PropagationTimeout time.Duration
+	PollingInterval    time.Duration
+	TTL                int
+	DisableCP          bool
+}
+
+// initDNSConfig 在 base 的基础上覆盖设置的值
+func initDNSConfig(base DNSConf, resolvers []string, timeout string, interval string, ttl int) (DNSConf, *errors.Error) {
+	result := base
+
+	if len(resolvers) > 0 {
+		result.Resolvers = resolvers
+	}
+
+	if len(timeout) > 0 {
+		duration, err := ParseDuration(timeout, false)
+		if err != nil {
+			return result, errors.NewError(errors.ConfigParseDurationErrno, err, "propagation-timeout")
+		}
+
+		result.PropagationTimeout = duration
+	}
+
+	if len(interval) > 0 {
+		duration, err := ParseDuration(interval, false)
+		if err != nil {
+			return result, errors.NewError(errors.ConfigParseDurationErrno, err, "polling-interval")
+		}
+
+		result.PollingInterval = duration
+	}
+
+	if ttl > 0 {
+		result.TTL = ttl
+	}
+
+	return result, nil
+}
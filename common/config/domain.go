@@ -15,15 +15,82 @@ type DomainConf struct {
 	KeyType   []certcrypto.KeyType
 	Challenge string
 	Options   map[string]string
+
+	Challenges        map[string]string
+	WildcardChallenge string
+
+	AcmeURL    string
+	Email      string
+	EABKid     string
+	EABHmacKey string
+
+	DNS DNSConf
+
+	SelfCheck  bool
+	MustStaple bool
+
+	PreHook    string
+	PostHook   string
+	DeployHook string
+
+	Outputs []OutputConf
+
+	File     FileConf
+	DeployTo []DeployConf
 }
 
 const defaultChallenge = "http-path"
 
-func initDomainConfig(domain string, conf *domainTOML, types []certcrypto.KeyType, base *baseTOML) (*DomainConf, *errors.Error) {
+func initDomainConfig(domain string, conf *domainTOML, types []certcrypto.KeyType, base *baseTOML, dns DNSConf, file FileConf) (*DomainConf, *errors.Error) {
+	list := &problemList{tree: base.tree}
+	if validateDomain(domain, conf, base, list); list.firstError() != nil {
+		return nil, errors.NewError(errors.ConfigValidateErrno, nil, list.firstError())
+	}
+
 	result := &DomainConf{
-		Domains:   buildDomains(domain, conf.Domains),
-		Challenge: common.DefaultString(common.DefaultString(conf.Challenge, base.Challenge), defaultChallenge),
-		Options:   conf.Options,
+		Domains:    buildDomains(domain, conf.Domains),
+		Challenge:  common.DefaultString(common.DefaultString(conf.Challenge, base.Challenge), defaultChallenge),
+		Options:    conf.Options,
+		Challenges: map[string]string{},
+		Email:      common.DefaultString(conf.Email, base.Email),
+		EABKid:     common.DefaultString(conf.EABKid, base.EABKid),
+		EABHmacKey: common.DefaultString(conf.EABHmacKey, base.EABHmacKey),
+		SelfCheck:  base.SelfCheck,
+		MustStaple: base.MustStaple,
+		PreHook:    common.DefaultString(conf.PreHook, base.PreHook),
+		PostHook:   common.DefaultString(conf.PostHook, base.PostHook),
+		DeployHook: common.DefaultString(conf.DeployHook, base.DeployHook),
+	}
+
+	if conf.SelfCheck != nil {
+		result.SelfCheck = *conf.SelfCheck
+	}
+
+	if conf.MustStaple != nil {
+		result.MustStaple = *conf.MustStaple
+	}
+
+	// 域名可以使用单独的 CA 和账户
+	acmeURL, err := ResolveAcmeURL(common.DefaultString(conf.AcmeURL, base.AcmeURL))
+	if err != nil {
+		return nil, errors.NewError(errors.ConfigAcmeURLErrno, err, conf.AcmeURL)
+	}
+
+	result.AcmeURL = acmeURL
+
+	// DNS 验证的配置在全局配置的基础上覆盖
+	if conf.DisableCP != nil {
+		dns.DisableCP = *conf.DisableCP
+	}
+
+	result.DNS, err = initDNSConfig(dns, conf.DNSResolvers, conf.PropagationTimeout, conf.PollingInterval, conf.TTL)
+	if err != nil {
+		return nil, err
+	}
+
+	result.WildcardChallenge = common.DefaultString(conf.WildcardChallenge, base.WildcardChallenge)
+	for identifier, challenge := range conf.Challenges {
+		result.Challenges[strings.ToLower(identifier)] = challenge
 	}
 
 	keyType := []certcrypto.KeyType{certcrypto.RSA2048}
@@ -35,9 +102,40 @@ func initDomainConfig(domain string, conf *domainTOML, types []certcrypto.KeyTyp
 	}
 
 	result.KeyType = keyType
+
+	if result.File, err = initFileConfig(file, conf.Owner, conf.Group, conf.FileMode, conf.DirMode); err != nil {
+		return nil, err
+	}
+
+	// 额外输出的文件不合并, 域名配置了就覆盖全局配置
+	outputs := base.Outputs
+	if len(conf.Outputs) > 0 {
+		outputs = conf.Outputs
+	}
+
+	if result.Outputs, err = initOutputs(outputs, result.File.FileMode); err != nil {
+		return nil, err
+	}
+
+	result.DeployTo = initDeployConfig(conf.DeployTo)
+
 	return result, nil
 }
 
+// ChallengeFor 返回单个域名使用的验证方式
+func (conf *DomainConf) ChallengeFor(identifier string) string {
+	identifier = strings.ToLower(identifier)
+	if challenge, ok := conf.Challenges[identifier]; ok {
+		return challenge
+	}
+
+	if strings.HasPrefix(identifier, "*.") && len(conf.WildcardChallenge) > 0 {
+		return conf.WildcardChallenge
+	}
+
+	return conf.Challenge
+}
+
 func buildDomains(key string, list []string) []string {
 	list = append([]string{key}, list...)
 
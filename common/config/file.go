@@ -0,0 +1,113 @@
+package config
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+// FileConf 证书文件和目录的属主和权限, UID 和 GID 为 -1 时不修改
+type FileConf struct {
+	UID      int
+	GID      int
+	FileMode os.FileMode
+	DirMode  os.FileMode
+}
+
+// DeployConf 证书更新后复制到的目录
+type DeployConf struct {
+	Dir   string
+	Files map[string]string // 证书目录中的文件名 -> 目标文件名, 为空时复制全部文件
+}
+
+type deployTOML struct {
+	Dir   string            `toml:"dir"`
+	Files map[string]string `toml:"files"`
+}
+
+const (
+	defaultFileMode = "0600"
+	defaultDirMode  = "0700"
+)
+
+// initFileConfig 在 base 的基础上覆盖设置的值
+func initFileConfig(base FileConf, owner string, group string, fileMode string, dirMode string) (FileConf, *errors.Error) {
+	result := base
+
+	if len(owner) > 0 {
+		uid, err := lookupUser(owner)
+		if err != nil {
+			return result, errors.NewError(errors.ConfigFileOwnerErrno, err, "owner", owner)
+		}
+
+		result.UID = uid
+	}
+
+	if len(group) > 0 {
+		gid, err := lookupGroup(group)
+		if err != nil {
+			return result, errors.NewError(errors.ConfigFileOwnerErrno, err, "group", group)
+		}
+
+		result.GID = gid
+	}
+
+	if len(fileMode) > 0 {
+		mode, err := ParseFileMode(fileMode)
+		if err != nil {
+			return result, errors.NewError(errors.ConfigFileModeErrno, err, "file-mode", fileMode)
+		}
+
+		result.FileMode = mode
+	}
+
+	if len(dirMode) > 0 {
+		mode, err := ParseFileMode(dirMode)
+		if err != nil {
+			return result, errors.NewError(errors.ConfigFileModeErrno, err, "dir-mode", dirMode)
+		}
+
+		result.DirMode = mode
+	}
+
+	return result, nil
+}
+
+func initDeployConfig(list []deployTOML) []DeployConf {
+	result := []DeployConf{}
+	for _, item := range list {
+		result = append(result, DeployConf{Dir: item.Dir, Files: item.Files})
+	}
+
+	return result
+}
+
+// lookupUser 支持用户名和 UID
+func lookupUser(name string) (int, error) {
+	if uid, err := strconv.Atoi(name); err == nil {
+		return uid, nil
+	}
+
+	result, err := user.Lookup(name)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(result.Uid)
+}
+
+// lookupGroup 支持组名和 GID
+func lookupGroup(name string) (int, error) {
+	if gid, err := strconv.Atoi(name); err == nil {
+		return gid, nil
+	}
+
+	result, err := user.LookupGroup(name)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(result.Gid)
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/alphatr/acme-lego/common"
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+// OutputConf 证书额外输出的文件
+type OutputConf struct {
+	Format   string
+	Name     string      // 文件名中的 {key-type} 替换为证书类型
+	Mode     os.FileMode // 默认为 file-mode
+	Password string      // 只用于 pkcs12
+}
+
+type outputTOML struct {
+	Format   string `toml:"format"`
+	Name     string `toml:"name"`
+	Mode     string `toml:"mode"`
+	Password string `toml:"password"`
+}
+
+// OutputKeyType 文件名中证书类型的占位符
+const OutputKeyType = "{key-type}"
+
+// outputFormats 支持的输出格式和默认文件名
+var outputFormats = map[string]string{
+	"combined": "combined." + OutputKeyType + ".pem", // 私钥和完整证书链, 如 HAProxy
+	"pkcs12":   "keystore." + OutputKeyType + ".p12", // 私钥和完整证书链, 如 Tomcat, IIS
+	"der":      "cert." + OutputKeyType + ".der",     // DER 编码的证书, 不包括证书链
+	"cert":     "cert." + OutputKeyType + ".pem",     // 证书, 不包括证书链
+	"chain":    "chain." + OutputKeyType + ".pem",    // 证书链, 不包括证书
+}
+
+// initOutputs 没有设置 mode 时使用 file-mode
+func initOutputs(list []outputTOML, fileMode os.FileMode) ([]OutputConf, *errors.Error) {
+	result := []OutputConf{}
+	for _, item := range list {
+		name, ok := outputFormats[item.Format]
+		if !ok {
+			return nil, errors.NewError(errors.ConfigOutputErrno, nil, item.Format)
+		}
+
+		mode := fileMode
+		if len(item.Mode) > 0 {
+			value, err := ParseFileMode(item.Mode)
+			if err != nil {
+				return nil, errors.NewError(errors.ConfigOutputErrno, err, item.Format)
+			}
+
+			mode = value
+		}
+
+		result = append(result, OutputConf{
+			Format:   item.Format,
+			Name:     common.DefaultString(item.Name, name),
+			Mode:     mode,
+			Password: item.Password,
+		})
+	}
+
+	return result, nil
+}
+
+// ParseFileMode 解析八进制的文件权限, 如 0640
+func ParseFileMode(input string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(input, 8, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	if mode > 0777 {
+		return 0, strconv.ErrRange
+	}
+
+	return os.FileMode(mode), nil
+}
@@ -0,0 +1,493 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+
+	"github.com/alphatr/acme-lego/common"
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+// ChallengeOptions 验证方式支持的 options
+type ChallengeOptions struct {
+	Required  []string
+	Optional  []string
+	Durations []string // 值为时间间隔的 options
+	Servers   []string // 值为 host:port 监听地址的 options
+	Any       bool     // 不检查未知的 options, 如 lego 的 DNS Provider 使用环境变量名
+	DNS       bool     // DNS 验证, 泛域名只能使用 DNS 验证
+}
+
+// LookupChallenge 根据名称查找验证方式的 options, 由 model/challenge 设置, 避免循环引用
+var LookupChallenge func(name string) (*ChallengeOptions, bool)
+
+// Problem 配置中的问题, Warning 不影响配置加载
+type Problem struct {
+	Path    []string
+	Message string
+	Warning bool
+	Line    int
+	Column  int
+}
+
+func (problem *Problem) String() string {
+	location := strings.Join(problem.Path, ".")
+	if problem.Line > 0 {
+		location = fmt.Sprintf("line %d: %s", problem.Line, location)
+	}
+
+	return fmt.Sprintf("%s: %s", location, problem.Message)
+}
+
+// problemList 收集配置问题并从 TOML 树中获取位置
+type problemList struct {
+	tree  *toml.Tree
+	items []*Problem
+}
+
+func (list *problemList) add(warning bool, path []string, format string, argu ...interface{}) {
+	problem := &Problem{Path: path, Message: fmt.Sprintf(format, argu...), Warning: warning}
+	for _, item := range list.items {
+		if item.String() == problem.String() && item.Warning == warning {
+			return
+		}
+	}
+
+	// 选项不存在时向上查找最近的位置
+	for index := len(path); list.tree != nil && index > 0; index-- {
+		if position := positionOf(list.tree, path[:index]); !position.Invalid() {
+			problem.Line, problem.Column = position.Line, position.Col
+			break
+		}
+	}
+
+	list.items = append(list.items, problem)
+}
+
+// positionOf 返回配置项的位置, 数组表 (如 outputs) 使用下标访问其中的元素
+func positionOf(tree *toml.Tree, path []string) toml.Position {
+	for index := 0; index < len(path); index++ {
+		key := path[index]
+		last := index == len(path)-1
+
+		switch node := tree.GetPath([]string{key}).(type) {
+		case *toml.Tree:
+			if last {
+				return node.Position()
+			}
+
+			tree = node
+		case []*toml.Tree:
+			if last {
+				return tree.GetPositionPath([]string{key})
+			}
+
+			item, err := strconv.Atoi(path[index+1])
+			if err != nil || item < 0 || item >= len(node) {
+				return toml.Position{}
+			}
+
+			if index++; index == len(path)-1 {
+				return node[item].Position()
+			}
+
+			tree = node[item]
+		case nil:
+			return toml.Position{}
+		default:
+			if last {
+				return tree.GetPositionPath([]string{key})
+			}
+
+			return toml.Position{}
+		}
+	}
+
+	return toml.Position{}
+}
+
+// firstError 返回第一个不是 Warning 的问题
+func (list *problemList) firstError() *Problem {
+	for _, item := range list.items {
+		if !item.Warning {
+			return item
+		}
+	}
+
+	return nil
+}
+
+// validateBase 检查全局配置
+func validateBase(conf *baseTOML, list *problemList) {
+	if _, err := ResolveAcmeURL(conf.AcmeURL); err != nil {
+		list.add(false, []string{"acme-url"}, "invalid acme-url %q", conf.AcmeURL)
+	}
+
+	validateKeyTypes(conf.KeyType, []string{"key-type"}, list)
+	validateDuration(conf.RenewInterval, false, []string{"renew-interval"}, list)
+	validateDuration(conf.RenewJitter, true, []string{"renew-jitter"}, list)
+	validateDuration(conf.PropagationTimeout, false, []string{"propagation-timeout"}, list)
+	validateDuration(conf.PollingInterval, false, []string{"polling-interval"}, list)
+
+	for _, key := range []string{"challenge", "wildcard-challenge"} {
+		name := map[string]string{"challenge": conf.Challenge, "wildcard-challenge": conf.WildcardChallenge}[key]
+		if len(name) > 0 {
+			lookupChallenge(name, []string{key}, list)
+		}
+	}
+
+	validateOutputs(conf.Outputs, len(conf.KeyType), []string{"outputs"}, list)
+	validateFile(conf.Owner, conf.Group, conf.FileMode, conf.DirMode, nil, list)
+}
+
+// validateDomain 检查域名配置, 包括每个域名使用的验证方式和 options
+func validateDomain(domain string, conf *domainTOML, base *baseTOML, list *problemList) {
+	prefix := []string{"domain-group", domain}
+	path := func(keys ...string) []string {
+		return append(append([]string{}, prefix...), keys...)
+	}
+
+	if len(conf.AcmeURL) > 0 {
+		if _, err := ResolveAcmeURL(conf.AcmeURL); err != nil {
+			list.add(false, path("acme-url"), "invalid acme-url %q", conf.AcmeURL)
+		}
+	}
+
+	validateKeyTypes(conf.KeyType, path("key-type"), list)
+	validateDuration(conf.PropagationTimeout, false, path("propagation-timeout"), list)
+	validateDuration(conf.PollingInterval, false, path("polling-interval"), list)
+
+	domains := buildDomains(domain, conf.Domains)
+	exists := map[string]bool{}
+	for _, item := range domains {
+		exists[item] = true
+	}
+
+	for identifier := range conf.Challenges {
+		if !exists[strings.ToLower(identifier)] {
+			list.add(true, path("challenges", identifier), "%q is not a domain of the group", identifier)
+		}
+	}
+
+	result := &DomainConf{
+		Challenge:         common.DefaultString(common.DefaultString(conf.Challenge, base.Challenge), defaultChallenge),
+		Challenges:        map[string]string{},
+		WildcardChallenge: common.DefaultString(conf.WildcardChallenge, base.WildcardChallenge),
+	}
+
+	for identifier, challenge := range conf.Challenges {
+		result.Challenges[strings.ToLower(identifier)] = challenge
+	}
+
+	// 当前域名实际使用的验证方式
+	used := map[string]*ChallengeOptions{}
+	for _, identifier := range domains {
+		name := result.ChallengeFor(identifier)
+		options, ok := used[name]
+		if !ok {
+			if options, ok = lookupChallenge(name, challengePath(domain, identifier, name, conf, result), list); !ok {
+				continue
+			}
+
+			used[name] = options
+		}
+
+		if strings.HasPrefix(identifier, "*.") && !options.DNS {
+			list.add(false, challengePath(domain, identifier, name, conf, result), "wildcard domain %q requires a dns challenge, got %q", identifier, name)
+		}
+	}
+
+	validateOptions(conf.Options, used, path("options"), list)
+
+	keyTypes := len(conf.KeyType)
+	if keyTypes == 0 {
+		keyTypes = len(base.KeyType)
+	}
+
+	if len(conf.Outputs) > 0 {
+		validateOutputs(conf.Outputs, keyTypes, path("outputs"), list)
+	} else {
+		validateOutputs(base.Outputs, keyTypes, []string{"outputs"}, list)
+	}
+
+	validateFile(conf.Owner, conf.Group, conf.FileMode, conf.DirMode, prefix, list)
+	validateDeployTo(conf.DeployTo, keyTypes, path("deploy-to"), list)
+}
+
+// validateFile 检查属主和权限
+func validateFile(owner string, group string, fileMode string, dirMode string, prefix []string, list *problemList) {
+	path := func(key string) []string {
+		return append(append([]string{}, prefix...), key)
+	}
+
+	if len(owner) > 0 {
+		if _, err := lookupUser(owner); err != nil {
+			list.add(false, path("owner"), "unknown user %q", owner)
+		}
+	}
+
+	if len(group) > 0 {
+		if _, err := lookupGroup(group); err != nil {
+			list.add(false, path("group"), "unknown group %q", group)
+		}
+	}
+
+	modes := map[string]string{"file-mode": fileMode, "dir-mode": dirMode}
+	for _, key := range []string{"file-mode", "dir-mode"} {
+		if len(modes[key]) == 0 {
+			continue
+		}
+
+		if _, err := ParseFileMode(modes[key]); err != nil {
+			list.add(false, path(key), "invalid file mode %q", modes[key])
+		}
+	}
+}
+
+// validateDeployTo 检查复制证书的目录和文件名, 多个证书类型的目标文件名需要包含 {key-type}
+func validateDeployTo(targets []deployTOML, keyTypes int, path []string, list *problemList) {
+	itemPath := func(index int, keys ...string) []string {
+		return append(append(append([]string{}, path...), strconv.Itoa(index)), keys...)
+	}
+
+	for index, item := range targets {
+		if !filepath.IsAbs(item.Dir) {
+			list.add(false, itemPath(index, "dir"), "deploy-to dir %q must be an absolute path", item.Dir)
+		}
+
+		sources := []string{}
+		for source := range item.Files {
+			sources = append(sources, source)
+		}
+
+		sort.Strings(sources)
+		names := map[string]bool{}
+		for _, source := range sources {
+			target := item.Files[source]
+			switch {
+			case filepath.Base(source) != source:
+				list.add(false, itemPath(index, "files", source), "deploy-to file %q must be a file name", source)
+			case filepath.Base(target) != target || target == "." || target == "..":
+				list.add(false, itemPath(index, "files", source), "deploy-to file %q must be a file name", target)
+			case names[target]:
+				list.add(false, itemPath(index, "files", source), "deploy-to file %q is used more than once", target)
+			case keyTypes > 1 && !strings.Contains(target, OutputKeyType):
+				list.add(false, itemPath(index, "files", source), "deploy-to file %q must contain %s for multiple key types", target, OutputKeyType)
+			}
+
+			names[target] = true
+		}
+	}
+}
+
+// validateOutputs 检查额外输出的文件, 多个证书类型的文件名需要包含 {key-type}
+func validateOutputs(outputs []outputTOML, keyTypes int, path []string, list *problemList) {
+	itemPath := func(index int, key string) []string {
+		return append(append([]string{}, path...), strconv.Itoa(index), key)
+	}
+
+	names := map[string]bool{}
+	for index, item := range outputs {
+		name, ok := outputFormats[item.Format]
+		if !ok {
+			list.add(false, itemPath(index, "format"), "unknown output format %q, supported combined, pkcs12, der, cert, chain", item.Format)
+			continue
+		}
+
+		if len(item.Mode) > 0 {
+			if _, err := ParseFileMode(item.Mode); err != nil {
+				list.add(false, itemPath(index, "mode"), "invalid file mode %q", item.Mode)
+			}
+		}
+
+		name = common.DefaultString(item.Name, name)
+		switch {
+		case filepath.Base(name) != name || name == "." || name == "..":
+			list.add(false, itemPath(index, "name"), "output name %q must be a file name", name)
+		case reservedOutputName(name):
+			list.add(false, itemPath(index, "name"), "output name %q conflicts with the certificate files", name)
+		case names[name]:
+			list.add(false, itemPath(index, "name"), "output name %q is used more than once", name)
+		case keyTypes > 1 && !strings.Contains(name, OutputKeyType):
+			list.add(false, itemPath(index, "name"), "output name %q must contain %s for multiple key types", name, OutputKeyType)
+		}
+
+		names[name] = true
+		if item.Format != "pkcs12" && len(item.Password) > 0 {
+			list.add(true, itemPath(index, "password"), "password is only used by pkcs12")
+		}
+	}
+}
+
+// reservedOutputName 证书目录中已经使用的文件名
+func reservedOutputName(name string) bool {
+	for _, prefix := range []string{"fullchain.", "privkey.", "meta.", "issuer.", "current.", "ocsp.", "acme-dns.json", "revoked"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateOptions 检查必需的 options, 以及所有验证方式都不支持的 options
+func validateOptions(options map[string]string, used map[string]*ChallengeOptions, path []string, list *problemList) {
+	names := []string{}
+	for name := range used {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	known := map[string]bool{}
+	durations := map[string]bool{}
+	servers := map[string]bool{}
+	anyOption := false
+	for _, name := range names {
+		item := used[name]
+		anyOption = anyOption || item.Any
+
+		for _, key := range item.Required {
+			known[key] = true
+			if len(options[key]) == 0 {
+				list.add(false, append(append([]string{}, path...), key), "option %q is required by %s", key, name)
+			}
+		}
+
+		for _, key := range item.Optional {
+			known[key] = true
+		}
+
+		for _, key := range item.Durations {
+			durations[key] = true
+		}
+
+		for _, key := range item.Servers {
+			servers[key] = true
+		}
+	}
+
+	keys := []string{}
+	for key := range options {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		if durations[key] {
+			validateDuration(options[key], false, append(append([]string{}, path...), key), list)
+		}
+
+		if !servers[key] || len(options[key]) == 0 {
+			continue
+		}
+
+		if _, _, err := ParseServer(options[key]); err != nil {
+			list.add(false, append(append([]string{}, path...), key), "invalid server %q, expected host:port", options[key])
+		}
+	}
+
+	if anyOption || len(used) == 0 {
+		return
+	}
+
+	for _, key := range keys {
+		if !known[key] {
+			list.add(true, append(append([]string{}, path...), key), "option %q is not used by %s", key, strings.Join(names, ", "))
+		}
+	}
+}
+
+func validateKeyTypes(input []string, path []string, list *problemList) {
+	for _, item := range input {
+		if len(KeyTypeList([]string{item})) == 0 {
+			list.add(false, path, "unknown key-type %q, supported ec256, ec384, rsa2048, rsa4096, rsa8192", item)
+		}
+	}
+}
+
+// validateDuration 使用和配置加载相同的规则检查时间间隔, 为空时使用默认值
+func validateDuration(value string, zero bool, path []string, list *problemList) {
+	if len(value) == 0 {
+		return
+	}
+
+	if _, err := ParseDuration(value, zero); err != nil {
+		list.add(false, path, "invalid duration %q", value)
+	}
+}
+
+func lookupChallenge(name string, path []string, list *problemList) (*ChallengeOptions, bool) {
+	if LookupChallenge == nil {
+		return nil, false
+	}
+
+	options, ok := LookupChallenge(name)
+	if !ok {
+		list.add(false, path, "unknown challenge %q", name)
+		return nil, false
+	}
+
+	return options, true
+}
+
+// challengePath 返回域名使用的验证方式所在的配置项
+func challengePath(domain, identifier, name string, conf *domainTOML, result *DomainConf) []string {
+	for key, value := range conf.Challenges {
+		if strings.ToLower(key) == identifier && value == name {
+			return []string{"domain-group", domain, "challenges", key}
+		}
+	}
+
+	if strings.HasPrefix(identifier, "*.") && name == result.WildcardChallenge {
+		if len(conf.WildcardChallenge) > 0 {
+			return []string{"domain-group", domain, "wildcard-challenge"}
+		}
+
+		return []string{"wildcard-challenge"}
+	}
+
+	if len(conf.Challenge) > 0 {
+		return []string{"domain-group", domain, "challenge"}
+	}
+
+	return []string{"challenge"}
+}
+
+// CheckConfig 检查配置文件, 返回全部问题
+func CheckConfig(configPath string) ([]*Problem, *errors.Error) {
+	configPath, errs := filepath.Abs(configPath)
+	if errs != nil {
+		return nil, errors.NewError(errors.CommonPathAbsErrno, errs, configPath)
+	}
+
+	conf, err := parseTOML(configPath)
+	if err != nil {
+		return nil, errors.NewError(errors.ConfigParseTOMLErrno, err)
+	}
+
+	list := &problemList{tree: conf.tree}
+	validateBase(conf, list)
+
+	domains := []string{}
+	for domain := range conf.DomainGroup {
+		domains = append(domains, domain)
+	}
+
+	sort.Strings(domains)
+	for _, domain := range domains {
+		value := conf.DomainGroup[domain]
+		validateDomain(domain, &value, conf, list)
+	}
+
+	sort.SliceStable(list.items, func(i, j int) bool {
+		return list.items[i].Line < list.items[j].Line
+	})
+
+	return list.items, nil
+}
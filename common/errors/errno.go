@@ -18,19 +18,32 @@ const (
 	CommonPathAbsErrno             ErrorNum = 20001006
 	CommonMakeDirErrno             ErrorNum = 20001007
 	CommonFileIsExistErrno         ErrorNum = 20001008
+	CommonFileRenameErrno          ErrorNum = 20001009
+	CommonFileSyncErrno            ErrorNum = 20001010
+	CommonFileSymlinkErrno         ErrorNum = 20001011
+	CommonFileChownErrno           ErrorNum = 20001012
+	CommonFileChmodErrno           ErrorNum = 20001013
 	CommonTOMLUnmarshalErrno       ErrorNum = 20002001
 	CommonJSONUnmarshalErrno       ErrorNum = 20003001
 	CommonJSONMarshalErrno         ErrorNum = 20003002
 	CommonCommandRunErrno          ErrorNum = 20004001
+	CommonCommandTimeoutErrno      ErrorNum = 20004002
 	CommonParsePrivateErrno        ErrorNum = 20005001
 	CommonParseCertificateErrno    ErrorNum = 20005002
 	CommonUnknowBlockErrno         ErrorNum = 20005003
 	CommonMarshalPrivateErrno      ErrorNum = 20005004
 	CommonParseHostPortErrno       ErrorNum = 20006001
+	CommonParseURLErrno            ErrorNum = 20006002
 	ConfigInitErrno                ErrorNum = 20101001
 	ConfigParseTOMLErrno           ErrorNum = 20101002
 	ConfigBaseInitErrno            ErrorNum = 20102001
+	ConfigParseDurationErrno       ErrorNum = 20102002
+	ConfigAcmeURLErrno             ErrorNum = 20102003
 	ConfigDomainInitErrno          ErrorNum = 20103001
+	ConfigOutputErrno              ErrorNum = 20103002
+	ConfigFileOwnerErrno           ErrorNum = 20103003
+	ConfigFileModeErrno            ErrorNum = 20103004
+	ConfigValidateErrno            ErrorNum = 20104001
 	BootstrapInitErrno             ErrorNum = 20201001
 	BootstrapInitLoggerErrno       ErrorNum = 20202001
 	BootstrapInitHandlerErrno      ErrorNum = 20203001
@@ -50,14 +63,29 @@ const (
 	ConCertSaveCertErrno           ErrorNum = 30301007
 	ConCertRunAfterRenewErrno      ErrorNum = 30301008
 	ConCertLoadPrivateErrno        ErrorNum = 30301009
-	ConCertRenewIgnoreErrno        ErrorNum = 30301010
+	ConCertRevokeErrno             ErrorNum = 30301011
+	ConCertArchiveErrno            ErrorNum = 30301012
+	ConCertSummaryFailedErrno      ErrorNum = 30301013
+	ConCertRequireManualErrno      ErrorNum = 30301014
+	ConCertSelfCheckErrno          ErrorNum = 30301015
+	ConCertRunHookErrno            ErrorNum = 30301016
+	ConCertRollbackErrno           ErrorNum = 30301017
+	ConCertNoPreviousErrno         ErrorNum = 30301018
+	ConCertOutputErrno             ErrorNum = 30301019
+	ConCertDeployErrno             ErrorNum = 30301020
+	ConCertOCSPErrno               ErrorNum = 30301021
+	ConCertOCSPStatusErrno         ErrorNum = 30301022
 	ModelClientInitErrno           ErrorNum = 40101001
 	ModelClientRegisterErrno       ErrorNum = 40101002
 	ModelClientObtainErrno         ErrorNum = 40101003
+	ModelClientRevokeErrno         ErrorNum = 40101004
+	ModelClientCABundleErrno       ErrorNum = 40101005
+	ModelClientEABRequiredErrno    ErrorNum = 40101006
+	ModelClientOCSPErrno           ErrorNum = 40101007
 	ModelClientUnknowProviderErrno ErrorNum = 40101101
 	ModelClientProviderErrno       ErrorNum = 40101102
-	ModelClientSetProviderErrno    ErrorNum = 40101103
-	ModelClientTypeProviderErrno   ErrorNum = 40101104
+	ModelClientNoSolverErrno       ErrorNum = 40101105
+	ModelClientChallengeOfferErrno ErrorNum = 40101106
 	ModelAccSaveConfigErrno        ErrorNum = 40201001
 	ModelAccSavePrivateErrno       ErrorNum = 40201002
 	ModelAccLoadPrivateErrno       ErrorNum = 40201003
@@ -66,6 +94,11 @@ const (
 	ModelChalHTTPInitErrno         ErrorNum = 40301001
 	ModelChalServerStartErrno      ErrorNum = 40301002
 	ModelChalDNSConfigErrno        ErrorNum = 40301003
+	ModelChalTLSCertErrno          ErrorNum = 40301004
+	ModelChalExecConfigErrno       ErrorNum = 40301005
+	ModelChalManualErrno           ErrorNum = 40301006
+	ModelChalManualPollErrno       ErrorNum = 40301007
+	ModelChalSelfCheckErrno        ErrorNum = 40301008
 	UnknowErrno                    ErrorNum = 90000000
 )
 
@@ -80,19 +113,32 @@ var ErrorMap = map[ErrorNum]ErrorContent{
 	CommonPathAbsErrno:             {"get-absolute-path(%s)", 0},
 	CommonMakeDirErrno:             {"mkdir(%s)", 0},
 	CommonFileIsExistErrno:         {"file-is-exist(%s)", 0},
+	CommonFileRenameErrno:          {"rename-file(%s)", 0},
+	CommonFileSyncErrno:            {"sync-file(%s)", 0},
+	CommonFileSymlinkErrno:         {"symlink(%s)", 0},
+	CommonFileChownErrno:           {"chown(%s)", 0},
+	CommonFileChmodErrno:           {"chmod(%s)", 0},
 	CommonTOMLUnmarshalErrno:       {"toml-decode", 0},
 	CommonJSONUnmarshalErrno:       {"json-unmarshal", 0},
 	CommonJSONMarshalErrno:         {"json-marshal", 0},
 	CommonCommandRunErrno:          {"run-command(%s)", 0},
+	CommonCommandTimeoutErrno:      {"command-timeout(%s)", 0},
 	CommonParsePrivateErrno:        {"parse-private-key(%s)", 0},
 	CommonParseCertificateErrno:    {"parse-certificate(%s)", 0},
 	CommonUnknowBlockErrno:         {"unknow-pem-block(%s)", 0},
 	CommonMarshalPrivateErrno:      {"marshal-private-key(%s)", 0},
 	CommonParseHostPortErrno:       {"parse-host-port", 0},
+	CommonParseURLErrno:            {"parse-url(%s)", 0},
 	ConfigInitErrno:                {"init-config", 0},
 	ConfigParseTOMLErrno:           {"parse-toml-config", 0},
 	ConfigBaseInitErrno:            {"init-base-config", 0},
+	ConfigParseDurationErrno:       {"parse-duration(%s)", 0},
+	ConfigAcmeURLErrno:             {"acme-url(%s)", 0},
 	ConfigDomainInitErrno:          {"init-domain-config", 0},
+	ConfigOutputErrno:              {"invalid-output(%s)", 0},
+	ConfigFileOwnerErrno:           {"lookup-%s(%s)", 0},
+	ConfigFileModeErrno:            {"invalid-%s(%s)", 0},
+	ConfigValidateErrno:            {"invalid-config(%s)", 0},
 	BootstrapInitErrno:             {"init-bootstrap", 0},
 	BootstrapInitLoggerErrno:       {"init-logger", 0},
 	BootstrapInitHandlerErrno:      {"bootstrap-init-handle(%s)", 0},
@@ -112,14 +158,29 @@ var ErrorMap = map[ErrorNum]ErrorContent{
 	ConCertSaveCertErrno:           {"save-certificate(%s, %s)", 0},
 	ConCertRunAfterRenewErrno:      {"run-after-renew", 0},
 	ConCertLoadPrivateErrno:        {"load-private-key", 0},
-	ConCertRenewIgnoreErrno:        {"renew-ignore", 0},
+	ConCertRevokeErrno:             {"revoke-certificate(%s, %s)", 0},
+	ConCertArchiveErrno:            {"archive-certificate(%s, %s)", 0},
+	ConCertSummaryFailedErrno:      {"%s-failed(%d)", 0},
+	ConCertRequireManualErrno:      {"dns-manual-requires-terminal(%s)", 0},
+	ConCertSelfCheckErrno:          {"self-check-failed(%s)", 0},
+	ConCertRunHookErrno:            {"run-%s(%s)", 0},
+	ConCertRollbackErrno:           {"rollback-certificate(%s, %s)", 0},
+	ConCertNoPreviousErrno:         {"no-previous-version(%s)", 0},
+	ConCertOutputErrno:             {"write-output(%s, %s)", 0},
+	ConCertDeployErrno:             {"deploy-certificate(%s, %s)", 0},
+	ConCertOCSPErrno:               {"update-ocsp(%s, %s)", 0},
+	ConCertOCSPStatusErrno:         {"ocsp-status(%s)", 0},
 	ModelClientInitErrno:           {"init-client", 0},
 	ModelClientRegisterErrno:       {"register-account", 0},
 	ModelClientObtainErrno:         {"obtain-certificate", 0},
+	ModelClientRevokeErrno:         {"revoke-certificate", 0},
+	ModelClientCABundleErrno:       {"load-ca-bundle(%s)", 0},
+	ModelClientEABRequiredErrno:    {"external-account-binding-required", 0},
+	ModelClientOCSPErrno:           {"get-ocsp-response", 0},
 	ModelClientUnknowProviderErrno: {"unknow-provider(%s)", 0},
 	ModelClientProviderErrno:       {"provider-server", 0},
-	ModelClientSetProviderErrno:    {"client-set-provider", 0},
-	ModelClientTypeProviderErrno:   {"client-set-type-provider(%s)", 0},
+	ModelClientNoSolverErrno:       {"no-solver(%s)", 0},
+	ModelClientChallengeOfferErrno: {"challenge-not-offered(%s, %s)", 0},
 	ModelAccSaveConfigErrno:        {"save-account-config", 0},
 	ModelAccSavePrivateErrno:       {"save-account-private-key", 0},
 	ModelAccLoadPrivateErrno:       {"load-account-private-key", 0},
@@ -128,5 +189,10 @@ var ErrorMap = map[ErrorNum]ErrorContent{
 	ModelChalHTTPInitErrno:         {"init-http-provider", 0},
 	ModelChalServerStartErrno:      {"server-start", 0},
 	ModelChalDNSConfigErrno:        {"init-dns-config(%s)", 0},
+	ModelChalTLSCertErrno:          {"tls-alpn-certificate(%s)", 0},
+	ModelChalExecConfigErrno:       {"init-exec-provider(%s)", 0},
+	ModelChalManualErrno:           {"requires-manual-action(%s)", 0},
+	ModelChalManualPollErrno:       {"manual-record-not-visible(%s)", 0},
+	ModelChalSelfCheckErrno:        {"self-check(%s)", 0},
 	UnknowErrno:                    {"unknow-error %s", 0},
 }
@@ -0,0 +1,111 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+// WriteFileSync 写入文件并同步到磁盘, 文件权限不受 umask 影响
+func WriteFileSync(file string, content []byte, perm os.FileMode) *errors.Error {
+	handle, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return errors.NewError(errors.CommonFileCreateErrno, err, file)
+	}
+
+	if err := handle.Chmod(perm); err != nil {
+		handle.Close()
+		return errors.NewError(errors.CommonFileChmodErrno, err, file)
+	}
+
+	if _, err := handle.Write(content); err != nil {
+		handle.Close()
+		return errors.NewError(errors.CommonFileWriteErrno, err, file)
+	}
+
+	if err := handle.Sync(); err != nil {
+		handle.Close()
+		return errors.NewError(errors.CommonFileSyncErrno, err, file)
+	}
+
+	if err := handle.Close(); err != nil {
+		return errors.NewError(errors.CommonFileCloseErrno, err, file)
+	}
+
+	return nil
+}
+
+// WriteFileAtomic 先写入临时文件并设置属主, 再重命名为目标文件
+func WriteFileAtomic(file string, content []byte, perm os.FileMode, uid int, gid int) *errors.Error {
+	temp := filepath.Join(filepath.Dir(file), "."+filepath.Base(file)+".tmp")
+	if err := WriteFileSync(temp, content, perm); err != nil {
+		os.Remove(temp)
+		return err
+	}
+
+	if err := Chown(temp, uid, gid); err != nil {
+		os.Remove(temp)
+		return err
+	}
+
+	if err := os.Rename(temp, file); err != nil {
+		os.Remove(temp)
+		return errors.NewError(errors.CommonFileRenameErrno, err, temp)
+	}
+
+	return SyncDir(filepath.Dir(file))
+}
+
+// Chown 修改属主, uid 和 gid 都为 -1 时不修改
+func Chown(file string, uid int, gid int) *errors.Error {
+	if uid == -1 && gid == -1 {
+		return nil
+	}
+
+	if err := os.Chown(file, uid, gid); err != nil {
+		return errors.NewError(errors.CommonFileChownErrno, err, file)
+	}
+
+	return nil
+}
+
+// SyncDir 同步目录, 保证目录中新建和重命名的文件落盘
+func SyncDir(dir string) *errors.Error {
+	// Windows 不支持同步目录
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	handle, err := os.Open(dir)
+	if err != nil {
+		return errors.NewError(errors.CommonFileReadErrno, err, dir)
+	}
+
+	defer handle.Close()
+	if err := handle.Sync(); err != nil {
+		return errors.NewError(errors.CommonFileSyncErrno, err, dir)
+	}
+
+	return nil
+}
+
+// ReplaceSymlink 原子地创建或者替换软链接
+func ReplaceSymlink(target string, link string) *errors.Error {
+	temp := link + ".tmp"
+	if err := os.Remove(temp); err != nil && !os.IsNotExist(err) {
+		return errors.NewError(errors.CommonFileSymlinkErrno, err, temp)
+	}
+
+	if err := os.Symlink(target, temp); err != nil {
+		return errors.NewError(errors.CommonFileSymlinkErrno, err, temp)
+	}
+
+	if err := os.Rename(temp, link); err != nil {
+		os.Remove(temp)
+		return errors.NewError(errors.CommonFileRenameErrno, err, temp)
+	}
+
+	return nil
+}
@@ -1,6 +1,8 @@
 package account
 
 import (
+	"strings"
+
 	"github.com/urfave/cli/v2"
 
 	"github.com/alphatr/acme-lego/common"
@@ -13,9 +15,40 @@ import (
 
 // Register 注册账号
 func Register(ctx *cli.Context) error {
-	mail := common.DefaultString(ctx.String("mail"), config.Config.Email)
+	server := config.Config.AcmeURL
+	mail, eabKid, eabHmacKey := config.Config.Email, config.Config.EABKid, config.Config.EABHmacKey
+
+	// 使用域名配置的 CA 和账户
+	if domain := strings.ToLower(ctx.String("domain")); len(domain) > 0 {
+		conf, ok := config.Config.DomainGroup[domain]
+		if !ok {
+			err := errors.NewError(errors.ConErrorParamErrno, nil, "domain")
+			return cli.NewExitError(err.Error(), 205)
+		}
+
+		server, mail, eabKid, eabHmacKey = conf.AcmeURL, conf.Email, conf.EABKid, conf.EABHmacKey
+	}
+
+	if input := ctx.String("acme-url"); len(input) > 0 {
+		result, err := config.ResolveAcmeURL(input)
+		if err != nil {
+			err := errors.NewError(errors.ConErrorParamErrno, err, "acme-url")
+			return cli.NewExitError(err.Error(), 205)
+		}
+
+		server = result
+	}
+
+	mail = common.DefaultString(ctx.String("mail"), mail)
+	eabKid = common.DefaultString(ctx.String("eab-kid"), eabKid)
+	eabHmacKey = common.DefaultString(ctx.String("eab-hmac-key"), eabHmacKey)
+
+	if len(eabKid) > 0 && len(eabHmacKey) == 0 {
+		err := errors.NewError(errors.ConRequireParamErrno, nil, "eab-hmac-key")
+		return cli.NewExitError(err.Error(), 204)
+	}
 
-	acc, err := account.CreateAccount(mail, config.Config.RootDir)
+	acc, err := account.CreateAccount(mail, config.Config.RootDir, server)
 	if err != nil {
 		err := errors.NewError(errors.ConAccCreateErrno, err)
 		return cli.NewExitError(err.Error(), 201)
@@ -27,18 +60,19 @@ func Register(ctx *cli.Context) error {
 		return cli.NewExitError(err.Error(), 202)
 	}
 
-	reg, err := client.AccountRegister()
+	reg, err := client.AccountRegister(eabKid, eabHmacKey)
 	if err != nil {
 		err := errors.NewError(errors.ConAccRegisterErrno, err)
 		return cli.NewExitError(err.Error(), 203)
 	}
 
 	acc.Registration = reg
+	acc.EABKid = eabKid
 	if err := acc.Save(); err != nil {
 		err := errors.NewError(errors.ConAccSaveErrno, err)
 		return cli.NewExitError(err.Error(), 203)
 	}
 
-	bootstrap.Log.Infof("[success] registering-account: %s\n", acc.Email)
+	bootstrap.Log.Infof("[success] registering-account: %s (%s)\n", acc.Email, server)
 	return nil
 }
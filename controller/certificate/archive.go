@@ -0,0 +1,273 @@
+package certificate
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-acme/lego/v3/certcrypto"
+
+	"github.com/alphatr/acme-lego/common"
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+// archiveDir 证书的存档目录, 和 certificates 目录同级
+func archiveDir(certPath string) string {
+	return path.Join(path.Dir(path.Dir(certPath)), "archive", path.Base(certPath))
+}
+
+// archiveVersions 返回存档目录中全部的版本号, 从小到大排列
+func archiveVersions(dir string) ([]int, *errors.Error) {
+	items, errs := ioutil.ReadDir(dir)
+	if os.IsNotExist(errs) {
+		return nil, nil
+	}
+
+	if errs != nil {
+		return nil, errors.NewError(errors.CommonFileReadErrno, errs, dir)
+	}
+
+	versions := []int{}
+	for _, item := range items {
+		version, errs := strconv.Atoi(item.Name())
+		if !item.IsDir() || errs != nil || version <= 0 {
+			continue
+		}
+
+		versions = append(versions, version)
+	}
+
+	sort.Ints(versions)
+	return versions, nil
+}
+
+// newArchiveVersion 创建新的存档版本目录
+func newArchiveVersion(dir string, file config.FileConf) (string, *errors.Error) {
+	if err := checkFolder(dir); err != nil {
+		return "", err
+	}
+
+	versions, err := archiveVersions(dir)
+	if err != nil {
+		return "", err
+	}
+
+	next := 1
+	if len(versions) > 0 {
+		next = versions[len(versions)-1] + 1
+	}
+
+	versionPath := path.Join(dir, strconv.Itoa(next))
+	if err := os.Mkdir(versionPath, file.DirMode); err != nil {
+		return "", errors.NewError(errors.CommonMakeDirErrno, err, versionPath)
+	}
+
+	if err := os.Chmod(versionPath, file.DirMode); err != nil {
+		return "", errors.NewError(errors.CommonFileChmodErrno, err, versionPath)
+	}
+
+	if err := common.Chown(versionPath, file.UID, file.GID); err != nil {
+		return "", err
+	}
+
+	return versionPath, nil
+}
+
+// sameKeyVersions 返回和 versionPath 使用相同私钥的全部存档版本, 包括 versionPath 本身
+func sameKeyVersions(certPath string, keyType certcrypto.KeyType, versionPath string) ([]string, *errors.Error) {
+	result := []string{versionPath}
+	current := publicKeyBytes(generateFilePath(versionPath, keyType).Prev)
+	if current == nil {
+		return result, nil
+	}
+
+	dir := archiveDir(certPath)
+	versions, err := archiveVersions(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, version := range versions {
+		item := path.Join(dir, strconv.Itoa(version))
+		if item == path.Clean(versionPath) {
+			continue
+		}
+
+		if bytes.Equal(publicKeyBytes(generateFilePath(item, keyType).Prev), current) {
+			result = append(result, item)
+		}
+	}
+
+	return result, nil
+}
+
+// publicKeyBytes 私钥对应公钥的 DER 编码, 用于比较私钥是否相同, 读取失败时返回 nil
+func publicKeyBytes(keyFile string) []byte {
+	content, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil
+	}
+
+	key, err := certcrypto.ParsePEMPrivateKey(content)
+	if err != nil {
+		return nil
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil
+	}
+
+	result, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return nil
+	}
+
+	return result
+}
+
+// currentVersion 返回 current 软链接指向的版本号
+func currentVersion(certPath string, keyType certcrypto.KeyType) (int, *errors.Error) {
+	files := generateFilePath(certPath, keyType)
+
+	target, errs := os.Readlink(files.Current)
+	if errs != nil {
+		return 0, errors.NewError(errors.CommonFileReadErrno, errs, files.Current)
+	}
+
+	version, errs := strconv.Atoi(path.Base(target))
+	if errs != nil {
+		return 0, errors.NewError(errors.CommonFileReadErrno, errs, files.Current)
+	}
+
+	return version, nil
+}
+
+// switchVersion 原子地将 current 软链接切换到指定版本, 固定路径的文件都通过 current 指向版本中的文件
+func switchVersion(certPath string, keyType certcrypto.KeyType, versionPath string) *errors.Error {
+	files := generateFilePath(certPath, keyType)
+
+	target, errs := filepath.Rel(certPath, versionPath)
+	if errs != nil {
+		return errors.NewError(errors.CommonPathAbsErrno, errs, versionPath)
+	}
+
+	items, errs := ioutil.ReadDir(versionPath)
+	if errs != nil {
+		return errors.NewError(errors.CommonFileReadErrno, errs, versionPath)
+	}
+
+	if err := common.ReplaceSymlink(target, files.Current); err != nil {
+		return err
+	}
+
+	names := map[string]bool{}
+	for _, item := range items {
+		names[item.Name()] = true
+
+		link := path.Join(path.Base(files.Current), item.Name())
+		file := path.Join(certPath, item.Name())
+		if current, err := os.Readlink(file); err == nil && current == link {
+			continue
+		}
+
+		if err := common.ReplaceSymlink(link, file); err != nil {
+			return err
+		}
+	}
+
+	// 版本中没有的文件 (例如不再输出的格式) 不再保留链接
+	links, err := currentLinks(certPath, keyType)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range links {
+		if names[path.Base(file)] {
+			continue
+		}
+
+		if err := os.Remove(file); err != nil {
+			return errors.NewError(errors.CommonFileRenameErrno, err, file)
+		}
+	}
+
+	// 缓存的 OCSP 响应属于之前的证书, 等待重新获取
+	if err := os.Remove(files.OCSP); err != nil && !os.IsNotExist(err) {
+		return errors.NewError(errors.CommonFileRenameErrno, err, files.OCSP)
+	}
+
+	return common.SyncDir(certPath)
+}
+
+// currentLinks 返回证书目录中通过 current 软链接指向版本文件的全部链接
+func currentLinks(certPath string, keyType certcrypto.KeyType) ([]string, *errors.Error) {
+	items, errs := ioutil.ReadDir(certPath)
+	if errs != nil {
+		return nil, errors.NewError(errors.CommonFileReadErrno, errs, certPath)
+	}
+
+	prefix := path.Base(generateFilePath(certPath, keyType).Current) + "/"
+
+	result := []string{}
+	for _, item := range items {
+		if item.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+
+		file := path.Join(certPath, item.Name())
+		if target, err := os.Readlink(file); err == nil && strings.HasPrefix(target, prefix) {
+			result = append(result, file)
+		}
+	}
+
+	return result, nil
+}
+
+// migrateCertFiles 旧版本直接保存在证书目录中的文件作为存档的第一个版本
+func migrateCertFiles(certPath string, keyType certcrypto.KeyType, file config.FileConf) *errors.Error {
+	files := generateFilePath(certPath, keyType)
+	if info, err := os.Lstat(files.Cert); err != nil || !info.Mode().IsRegular() {
+		return nil
+	}
+
+	versionPath, err := newArchiveVersion(archiveDir(certPath), file)
+	if err != nil {
+		return err
+	}
+
+	// 先建立硬链接再切换, 切换过程中固定路径的文件一直存在
+	version := generateFilePath(versionPath, keyType)
+	for index, source := range files.list() {
+		if info, err := os.Lstat(source); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		target := version.list()[index]
+		if err := os.Link(source, target); err != nil {
+			return errors.NewError(errors.CommonFileCreateErrno, err, target)
+		}
+
+		if err := os.Chmod(target, file.FileMode); err != nil {
+			return errors.NewError(errors.CommonFileChmodErrno, err, target)
+		}
+
+		if err := common.Chown(target, file.UID, file.GID); err != nil {
+			return err
+		}
+	}
+
+	if err := common.SyncDir(versionPath); err != nil {
+		return err
+	}
+
+	return switchVersion(certPath, keyType, versionPath)
+}
@@ -1,6 +1,7 @@
 package certificate
 
 import (
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -11,14 +12,29 @@ import (
 	"github.com/go-acme/lego/v3/certcrypto"
 	"github.com/go-acme/lego/v3/certificate"
 
+	"github.com/alphatr/acme-lego/common"
+	"github.com/alphatr/acme-lego/common/config"
 	"github.com/alphatr/acme-lego/common/errors"
 )
 
 type certFilePath struct {
-	Cert   string
-	Prev   string
-	Meta   string
-	Issuer string
+	Cert    string
+	Prev    string
+	Meta    string
+	Issuer  string
+	Current string
+	OCSP    string
+}
+
+// list 证书的全部文件, 不包括 current 软链接
+func (ins *certFilePath) list() []string {
+	return []string{ins.Cert, ins.Prev, ins.Meta, ins.Issuer}
+}
+
+// certMeta 证书元数据, 在 lego 的 Resource 之外记录签发时的 must-staple 配置, 旧版本写入的元数据中没有该字段
+type certMeta struct {
+	certificate.Resource
+	MustStaple *bool `json:"mustStaple,omitempty"`
 }
 
 func checkFolder(path string) *errors.Error {
@@ -30,39 +46,114 @@ func checkFolder(path string) *errors.Error {
 	return nil
 }
 
-func saveCertRes(certRes *certificate.Resource, certPath string, keyType certcrypto.KeyType) *errors.Error {
-	files := generateFilePath(certPath, keyType)
+// prepareFolder 创建目录并设置权限和属主
+func prepareFolder(dir string, file config.FileConf) *errors.Error {
+	if err := os.MkdirAll(dir, file.DirMode); err != nil {
+		return errors.NewError(errors.CommonMakeDirErrno, err, dir)
+	}
 
-	if err := ioutil.WriteFile(files.Cert, certRes.Certificate, 0600); err != nil {
-		return errors.NewError(errors.CommonFileWriteErrno, err, files.Cert)
+	if err := os.Chmod(dir, file.DirMode); err != nil {
+		return errors.NewError(errors.CommonFileChmodErrno, err, dir)
 	}
 
-	if certRes.IssuerCertificate != nil {
-		if err := ioutil.WriteFile(files.Issuer, certRes.IssuerCertificate, 0600); err != nil {
-			return errors.NewError(errors.CommonFileWriteErrno, err, files.Issuer)
+	return common.Chown(dir, file.UID, file.GID)
+}
+
+// prepareCertFolders 设置域名的证书目录和存档目录, 不修改上级目录的权限
+func prepareCertFolders(certPath string, file config.FileConf) *errors.Error {
+	for _, dir := range []string{certPath, archiveDir(certPath)} {
+		if err := prepareFolder(dir, file); err != nil {
+			return err
 		}
 	}
 
-	// 提供 CSR 就不知道私钥了
-	if certRes.PrivateKey != nil {
-		if err := ioutil.WriteFile(files.Prev, certRes.PrivateKey, 0600); err != nil {
-			return errors.NewError(errors.CommonFileWriteErrno, err, files.Prev)
+	return nil
+}
+
+// saveCertRes 证书写入新的存档版本, 全部文件落盘后再原子地切换 current 软链接
+func saveCertRes(certRes *certificate.Resource, certPath string, keyType certcrypto.KeyType, conf *config.DomainConf) *errors.Error {
+	files := generateFilePath(certPath, keyType)
+	if err := prepareCertFolders(certPath, conf.File); err != nil {
+		return err
+	}
+
+	if err := migrateCertFiles(certPath, keyType, conf.File); err != nil {
+		return err
+	}
+
+	privateKey := certRes.PrivateKey
+
+	// 提供 CSR 就不知道私钥了, 沿用当前的私钥
+	if privateKey == nil {
+		content, errs := ioutil.ReadFile(files.Prev)
+		if errs != nil {
+			return errors.NewError(errors.CommonFileReadErrno, errs, files.Prev)
 		}
+
+		privateKey = content
+	}
+
+	meta, errs := json.MarshalIndent(&certMeta{Resource: *certRes, MustStaple: &conf.MustStaple}, "", "\t")
+	if errs != nil {
+		return errors.NewError(errors.CommonJSONMarshalErrno, errs)
 	}
 
-	content, err := json.MarshalIndent(certRes, "", "\t")
+	versionPath, err := newArchiveVersion(archiveDir(certPath), conf.File)
 	if err != nil {
-		return errors.NewError(errors.CommonJSONMarshalErrno, err)
+		return err
 	}
 
-	if err := ioutil.WriteFile(files.Meta, content, 0600); err != nil {
-		return errors.NewError(errors.CommonFileWriteErrno, err, files.Meta)
+	version := generateFilePath(versionPath, keyType)
+	contents := map[string][]byte{
+		version.Cert:   certRes.Certificate,
+		version.Prev:   privateKey,
+		version.Meta:   meta,
+		version.Issuer: certRes.IssuerCertificate,
 	}
 
-	return nil
+	for _, file := range version.list() {
+		if contents[file] == nil {
+			continue
+		}
+
+		if err := common.WriteFileSync(file, contents[file], conf.File.FileMode); err != nil {
+			return err
+		}
+
+		if err := common.Chown(file, conf.File.UID, conf.File.GID); err != nil {
+			return err
+		}
+	}
+
+	if err := writeOutputs(versionPath, certRes, privateKey, conf, keyType); err != nil {
+		return err
+	}
+
+	if err := common.SyncDir(versionPath); err != nil {
+		return err
+	}
+
+	if err := common.SyncDir(path.Dir(versionPath)); err != nil {
+		return err
+	}
+
+	return switchVersion(certPath, keyType, versionPath)
 }
 
 func generateFilePath(certPath string, keyType certcrypto.KeyType) *certFilePath {
+	keyTStr := keyTypeName(keyType)
+
+	return &certFilePath{
+		Cert:    path.Join(certPath, fmt.Sprintf("fullchain.%s.crt", keyTStr)),
+		Prev:    path.Join(certPath, fmt.Sprintf("privkey.%s.key", keyTStr)),
+		Meta:    path.Join(certPath, fmt.Sprintf("meta.%s.json", keyTStr)),
+		Issuer:  path.Join(certPath, fmt.Sprintf("issuer.%s.crt", keyTStr)),
+		Current: path.Join(certPath, fmt.Sprintf("current.%s", keyTStr)),
+		OCSP:    path.Join(certPath, fmt.Sprintf("ocsp.%s.der", keyTStr)),
+	}
+}
+
+func keyTypeName(keyType certcrypto.KeyType) string {
 	keyTypeMap := map[string]string{
 		"p256": "ecdsa-256",
 		"p384": "ecdsa-384",
@@ -71,12 +162,73 @@ func generateFilePath(certPath string, keyType certcrypto.KeyType) *certFilePath
 		"8192": "rsa-8192",
 	}
 
-	keyTStr := keyTypeMap[strings.ToLower(string(keyType))]
+	return keyTypeMap[strings.ToLower(string(keyType))]
+}
 
-	return &certFilePath{
-		Cert:   path.Join(certPath, fmt.Sprintf("fullchain.%s.crt", keyTStr)),
-		Prev:   path.Join(certPath, fmt.Sprintf("privkey.%s.key", keyTStr)),
-		Meta:   path.Join(certPath, fmt.Sprintf("meta.%s.json", keyTStr)),
-		Issuer: path.Join(certPath, fmt.Sprintf("issuer.%s.crt", keyTStr)),
+func loadCertificate(file string) (*x509.Certificate, *errors.Error) {
+	content, errs := ioutil.ReadFile(file)
+	if errs != nil {
+		return nil, errors.NewError(errors.CommonFileReadErrno, errs, file)
+	}
+
+	cert, errs := certcrypto.ParsePEMCertificate(content)
+	if errs != nil {
+		return nil, errors.NewError(errors.CommonParseCertificateErrno, errs, file)
+	}
+
+	return cert, nil
+}
+
+func archiveCertFiles(certPath string, keyType certcrypto.KeyType, archivePath string) *errors.Error {
+	files := generateFilePath(certPath, keyType)
+
+	// 使用被吊销私钥的存档版本都移动到吊销目录, 避免回滚到被吊销的证书或者私钥
+	if target, err := os.Readlink(files.Current); err == nil {
+		revokedPath := path.Join(archivePath, keyTypeName(keyType))
+		if err := checkFolder(revokedPath); err != nil {
+			return errors.NewError(errors.ConCertCheckFolderErrno, err, revokedPath)
+		}
+
+		versions, err := sameKeyVersions(certPath, keyType, path.Join(certPath, target))
+		if err != nil {
+			return err
+		}
+
+		for _, versionPath := range versions {
+			revoked := path.Join(revokedPath, path.Base(versionPath))
+			if err := os.Rename(versionPath, revoked); err != nil {
+				return errors.NewError(errors.CommonFileRenameErrno, err, versionPath)
+			}
+		}
+
+		links, err := currentLinks(certPath, keyType)
+		if err != nil {
+			return err
+		}
+
+		for _, file := range append(links, files.Current, files.OCSP) {
+			if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+				return errors.NewError(errors.CommonFileRenameErrno, err, file)
+			}
+		}
+
+		return common.SyncDir(certPath)
 	}
+
+	if err := checkFolder(archivePath); err != nil {
+		return errors.NewError(errors.ConCertCheckFolderErrno, err, archivePath)
+	}
+
+	for _, file := range files.list() {
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			continue
+		}
+
+		target := path.Join(archivePath, path.Base(file))
+		if err := os.Rename(file, target); err != nil {
+			return errors.NewError(errors.CommonFileRenameErrno, err, file)
+		}
+	}
+
+	return nil
 }
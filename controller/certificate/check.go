@@ -0,0 +1,119 @@
+package certificate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alphatr/acme-lego/common"
+	"github.com/alphatr/acme-lego/common/bootstrap"
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+	chall "github.com/alphatr/acme-lego/model/challenge"
+)
+
+// Check 检查 HTTP 验证是否可以访问, 不请求 CA
+func Check(ctx *cli.Context) error {
+	domains := []string{}
+	if domain := strings.ToLower(ctx.String("domain")); len(domain) > 0 {
+		if _, ok := config.Config.DomainGroup[domain]; !ok {
+			err := errors.NewError(errors.ConErrorParamErrno, nil, "domain")
+			return cli.NewExitError(err.Error(), 701)
+		}
+
+		domains = append(domains, domain)
+	} else {
+		for domain := range config.Config.DomainGroup {
+			domains = append(domains, domain)
+		}
+
+		sort.Strings(domains)
+	}
+
+	results := []*chall.CheckResult{}
+	for _, domain := range domains {
+		results = append(results, chall.SelfCheck(domain, config.Config.DomainGroup[domain])...)
+	}
+
+	writer := tabwriter.NewWriter(ctx.App.Writer, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "DOMAIN\tCHALLENGE\tADDRESSES\tRESULT")
+
+	failed := []string{}
+	for _, item := range results {
+		result := "ok"
+		if item.Error != nil {
+			result = item.Error.Error()
+			failed = append(failed, item.Domain)
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", item.Domain, item.Challenge,
+			common.DefaultString(strings.Join(item.Addresses, ","), "-"), result)
+	}
+
+	writer.Flush()
+
+	if len(failed) > 0 {
+		err := errors.NewError(errors.ConCertSelfCheckErrno, nil, strings.Join(failed, ","))
+		return cli.NewExitError(err.Error(), 702)
+	}
+
+	return nil
+}
+
+// selfCheck 开启 self-check 时申请前先检查, 避免失败的验证计入 CA 的频率限制
+func selfCheck(domain string, conf *config.DomainConf) *errors.Error {
+	if !conf.SelfCheck {
+		return nil
+	}
+
+	failed := []string{}
+	for _, item := range chall.SelfCheck(domain, conf) {
+		if item.Error != nil {
+			bootstrap.Log.Errorf("[%s] self-check: %s", item.Domain, item.Error)
+			failed = append(failed, item.Domain)
+		}
+	}
+
+	if len(failed) > 0 {
+		return errors.NewError(errors.ConCertSelfCheckErrno, nil, strings.Join(failed, ","))
+	}
+
+	return nil
+}
+
+// CheckConfig 检查配置文件, 输出全部问题及所在的行
+func CheckConfig(ctx *cli.Context) error {
+	configFile := ctx.String("config")
+
+	problems, err := config.CheckConfig(configFile)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 801)
+	}
+
+	errorCount := 0
+	for _, item := range problems {
+		level := "warning"
+		if !item.Warning {
+			level = "error"
+			errorCount++
+		}
+
+		location := configFile
+		if item.Line > 0 {
+			location = fmt.Sprintf("%s:%d:%d", configFile, item.Line, item.Column)
+		}
+
+		fmt.Fprintf(ctx.App.Writer, "%s: %s: %s: %s\n", location, level, strings.Join(item.Path, "."), item.Message)
+	}
+
+	fmt.Fprintf(ctx.App.Writer, "%s: %d error(s), %d warning(s)\n", configFile, errorCount, len(problems)-errorCount)
+	if errorCount > 0 {
+		err := errors.NewError(errors.ConfigValidateErrno, nil, configFile)
+		return cli.NewExitError(err.Error(), 802)
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package certificate
+
+import (
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+	"github.com/alphatr/acme-lego/model/account"
+	"github.com/alphatr/acme-lego/model/client"
+)
+
+// clientPool 按 CA 和账户复用客户端
+type clientPool struct {
+	clients map[string]*client.Client
+	errors  map[string]*errors.Error
+}
+
+func newClientPool() *clientPool {
+	return &clientPool{clients: map[string]*client.Client{}, errors: map[string]*errors.Error{}}
+}
+
+func (pool *clientPool) get(conf *config.DomainConf) (*client.Client, *errors.Error) {
+	key := conf.AcmeURL + "\n" + conf.Email
+	if lego, ok := pool.clients[key]; ok {
+		return lego, nil
+	}
+
+	if err, ok := pool.errors[key]; ok {
+		return nil, err
+	}
+
+	lego, err := newClient(conf)
+	if err != nil {
+		pool.errors[key] = err
+		return nil, err
+	}
+
+	pool.clients[key] = lego
+	return lego, nil
+}
+
+func newClient(conf *config.DomainConf) (*client.Client, *errors.Error) {
+	acc, err := account.GetAccount(config.Config.RootDir, conf.AcmeURL, conf.Email)
+	if err != nil {
+		return nil, errors.NewError(errors.ConGetAccountErrno, err)
+	}
+
+	lego, err := client.NewClient(acc)
+	if err != nil {
+		return nil, errors.NewError(errors.ConInitClientErrno, err)
+	}
+
+	return lego, nil
+}
@@ -0,0 +1,138 @@
+package certificate
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alphatr/acme-lego/common/bootstrap"
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+// Daemon 后台常驻, 定时检查并续期证书
+func Daemon(ctx *cli.Context) error {
+	rand.Seed(time.Now().UnixNano())
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	// done 在续期任务执行时不为空, 任务在单独的 goroutine 中执行, 收到停止信号时通过 cancel 通知任务在域名之间停止
+	task, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var done chan struct{}
+	reload := false
+
+	for {
+		select {
+		case sig := <-signals:
+			if sig != syscall.SIGHUP {
+				// 等待当前的域名处理完成, 保证清理、post-hook 和存档的写入不会被中断
+				if done != nil {
+					bootstrap.Log.Warnf("daemon-stop: %s, wait for the running renew task", sig)
+					cancel()
+					<-done
+					return nil
+				}
+
+				bootstrap.Log.Infof("daemon-stop: %s", sig)
+				return nil
+			}
+
+			// 任务执行中不替换配置, 结束后再重新加载
+			if done != nil {
+				bootstrap.Log.Infof("daemon-reload-config: wait for the running renew task")
+				reload = true
+				continue
+			}
+
+			reloadDaemonConfig(ctx.String("config"))
+
+		case <-timer.C:
+			done = make(chan struct{})
+			go func(done chan struct{}) {
+				renewTask(task)
+				close(done)
+			}(done)
+
+		case <-done:
+			done = nil
+			if reload {
+				reload = false
+				reloadDaemonConfig(ctx.String("config"))
+			}
+
+			interval := daemonInterval(ctx)
+			bootstrap.Log.Infof("daemon-next-check: %s", time.Now().Add(interval).Format(time.RFC3339))
+			timer.Reset(interval)
+		}
+	}
+}
+
+func renewTask(ctx context.Context) {
+	sum := renewGroups(ctx, config.Config.DomainGroup)
+	sum.print()
+
+	// 续期后立即获取新证书的 OCSP 响应
+	ocspSum := updateOCSPGroups(ctx, config.Config.DomainGroup, false)
+	ocspSum.print()
+
+	if sum.count(resultSuccess) > 0 || ocspSum.count(resultSuccess) > 0 {
+		if err := runAfterRenew(); err != nil {
+			bootstrap.Log.Errorf("daemon-renew: %s", err)
+		}
+	}
+}
+
+// daemonInterval 返回下次检查的间隔, 加上随机抖动避免多个实例同时请求 CA
+func daemonInterval(ctx *cli.Context) time.Duration {
+	interval := config.Config.RenewInterval
+	if ctx.IsSet("interval") && ctx.Duration("interval") > 0 {
+		interval = ctx.Duration("interval")
+	}
+
+	jitter := config.Config.RenewJitter
+	if ctx.IsSet("jitter") {
+		jitter = ctx.Duration("jitter")
+	}
+
+	if jitter > 0 {
+		interval += time.Duration(rand.Int63n(int64(jitter)))
+	}
+
+	return interval
+}
+
+func reloadDaemonConfig(configFile string) {
+	if err := reloadConfig(configFile); err != nil {
+		bootstrap.Log.Errorf("daemon-reload-config: %s", err)
+		return
+	}
+
+	bootstrap.Log.Infof("daemon-reload-config: %s", configFile)
+}
+
+func reloadConfig(configFile string) *errors.Error {
+	userAgent := config.Config.UserAgent
+
+	if err := config.InitConfig(configFile); err != nil {
+		return errors.NewError(errors.ConfigInitErrno, err)
+	}
+
+	config.Config.UserAgent = userAgent
+	if err := bootstrap.InitBootstrap(); err != nil {
+		return errors.NewError(errors.BootstrapInitErrno, err)
+	}
+
+	return nil
+}
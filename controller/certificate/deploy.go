@@ -0,0 +1,112 @@
+package certificate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-acme/lego/v3/certcrypto"
+
+	"github.com/alphatr/acme-lego/common"
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+// deployCopies 将证书文件复制到 deploy-to 目录, 内容没有变化的文件不再复制, 返回是否有文件更新
+func deployCopies(domain string, conf *config.DomainConf, keyType certcrypto.KeyType) (bool, *errors.Error) {
+	certPath := path.Join(config.Config.RootDir, "certificates", domain)
+
+	changed := false
+	for _, target := range conf.DeployTo {
+		files, err := deployFiles(certPath, target, keyType)
+		if err != nil {
+			return changed, errors.NewError(errors.ConCertDeployErrno, err, domain, target.Dir)
+		}
+
+		sources := []string{}
+		for source := range files {
+			sources = append(sources, source)
+		}
+
+		sort.Strings(sources)
+		for _, source := range sources {
+			updated, err := deployFile(path.Join(certPath, source), path.Join(target.Dir, files[source]), conf.File)
+			if err != nil {
+				return changed, errors.NewError(errors.ConCertDeployErrno, err, domain, target.Dir)
+			}
+
+			changed = changed || updated
+		}
+	}
+
+	return changed, nil
+}
+
+// deployFiles 返回需要复制的文件, 没有配置 files 时复制当前证书类型的全部文件; OCSP 响应还没有获取时跳过
+func deployFiles(certPath string, target config.DeployConf, keyType certcrypto.KeyType) (map[string]string, *errors.Error) {
+	ocspFile := generateFilePath(certPath, keyType).OCSP
+	_, errs := os.Stat(ocspFile)
+	hasOCSP := errs == nil
+
+	result := map[string]string{}
+	if len(target.Files) > 0 {
+		for source, name := range target.Files {
+			source = strings.Replace(source, config.OutputKeyType, keyTypeName(keyType), -1)
+			if source == path.Base(ocspFile) && !hasOCSP {
+				continue
+			}
+
+			result[source] = strings.Replace(name, config.OutputKeyType, keyTypeName(keyType), -1)
+		}
+
+		return result, nil
+	}
+
+	if hasOCSP {
+		result[path.Base(ocspFile)] = path.Base(ocspFile)
+	}
+
+	links, err := currentLinks(certPath, keyType)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range links {
+		result[path.Base(file)] = path.Base(file)
+	}
+
+	return result, nil
+}
+
+func deployFile(source string, target string, file config.FileConf) (bool, *errors.Error) {
+	info, errs := os.Stat(source)
+	if errs != nil {
+		return false, errors.NewError(errors.CommonFileNotExistErrno, errs, source)
+	}
+
+	content, errs := ioutil.ReadFile(source)
+	if errs != nil {
+		return false, errors.NewError(errors.CommonFileReadErrno, errs, source)
+	}
+
+	if current, err := ioutil.ReadFile(target); err == nil && bytes.Equal(current, content) {
+		return false, nil
+	}
+
+	// 只设置新建目录的权限, 已经存在的目录保持不变
+	if _, err := os.Stat(path.Dir(target)); os.IsNotExist(err) {
+		if err := prepareFolder(path.Dir(target), file); err != nil {
+			return false, err
+		}
+	}
+
+	// 保持和证书目录中的文件相同的权限, outputs 可以单独设置权限
+	if err := common.WriteFileAtomic(target, content, info.Mode().Perm(), file.UID, file.GID); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,127 @@
+package certificate
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-acme/lego/v3/certcrypto"
+
+	"github.com/alphatr/acme-lego/common"
+	"github.com/alphatr/acme-lego/common/bootstrap"
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+const hookTimeout = 10 * time.Minute
+
+// hookRunner 执行一次命令中的钩子, 相同的 pre-hook 和 post-hook 只执行一次
+type hookRunner struct {
+	pre  map[string]*errors.Error
+	post []string
+}
+
+func newHookRunner() *hookRunner {
+	return &hookRunner{pre: map[string]*errors.Error{}}
+}
+
+// before 在域名组第一次请求 CA 之前执行 pre-hook, 并记录结束时需要执行的 post-hook
+func (ins *hookRunner) before(conf *config.DomainConf) *errors.Error {
+	if len(conf.PostHook) > 0 && !ins.hasPost(conf.PostHook) {
+		ins.post = append(ins.post, conf.PostHook)
+	}
+
+	if len(conf.PreHook) == 0 {
+		return nil
+	}
+
+	if err, ok := ins.pre[conf.PreHook]; ok {
+		return err
+	}
+
+	err := runHook("pre-hook", conf.PreHook, nil)
+	ins.pre[conf.PreHook] = err
+	return err
+}
+
+// deploy 证书更新后复制到 deploy-to 目录, 并执行域名组的 deploy-hook
+func (ins *hookRunner) deploy(domain string, conf *config.DomainConf, keyType certcrypto.KeyType) *errors.Error {
+	if _, err := deployCopies(domain, conf, keyType); err != nil {
+		return err
+	}
+
+	return ins.deployHook(domain, conf, keyType)
+}
+
+// redeploy 证书没有更新时检查 deploy-to 目录, 文件缺失或者内容不一致时重新复制并执行 deploy-hook
+func (ins *hookRunner) redeploy(domain string, conf *config.DomainConf, keyType certcrypto.KeyType) *errors.Error {
+	changed, err := deployCopies(domain, conf, keyType)
+	if err != nil {
+		return err
+	}
+
+	if !changed {
+		return nil
+	}
+
+	bootstrap.Log.Infof("redeploy-cert: %s (%s)", domain, keyTypeName(keyType))
+	return ins.deployHook(domain, conf, keyType)
+}
+
+// deployHook 执行域名组的 deploy-hook, 失败时证书计为失败
+func (ins *hookRunner) deployHook(domain string, conf *config.DomainConf, keyType certcrypto.KeyType) *errors.Error {
+	if len(conf.DeployHook) == 0 {
+		return nil
+	}
+
+	certPath := path.Join(config.Config.RootDir, "certificates", domain)
+	files := generateFilePath(certPath, keyType)
+
+	env := []string{
+		fmt.Sprintf("LEGO_DOMAIN=%s", domain),
+		fmt.Sprintf("LEGO_KEY_TYPE=%s", keyTypeName(keyType)),
+		fmt.Sprintf("LEGO_CERT_PATH=%s", files.Cert),
+		fmt.Sprintf("LEGO_KEY_PATH=%s", files.Prev),
+		fmt.Sprintf("LEGO_CHAIN_PATH=%s", files.Issuer),
+		fmt.Sprintf("LEGO_RENEWED_DOMAINS=%s", strings.Join(conf.Domains, " ")),
+	}
+
+	return runHook("deploy-hook", conf.DeployHook, env)
+}
+
+func (ins *hookRunner) hasPost(command string) bool {
+	for _, item := range ins.post {
+		if item == command {
+			return true
+		}
+	}
+
+	return false
+}
+
+// finish 执行全部已请求过 CA 的域名组的 post-hook
+func (ins *hookRunner) finish() {
+	for _, command := range ins.post {
+		if err := runHook("post-hook", command, nil); err != nil {
+			bootstrap.Log.Errorf("post-hook: %s", err)
+		}
+	}
+
+	ins.post = nil
+}
+
+func runHook(name string, command string, env []string) *errors.Error {
+	bootstrap.Log.Infof("run-%s: %s", name, command)
+
+	result, err := common.RunCommandEnv(command, env, hookTimeout)
+	if result != "" {
+		bootstrap.Log.Debugf("%s-output: %s", name, result)
+	}
+
+	if err != nil {
+		return errors.NewError(errors.ConCertRunHookErrno, err, name, command)
+	}
+
+	return nil
+}
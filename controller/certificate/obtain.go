@@ -6,30 +6,17 @@ import (
 	"github.com/go-acme/lego/v3/certcrypto"
 	"github.com/urfave/cli/v2"
 
-	"github.com/alphatr/acme-lego/common/bootstrap"
 	"github.com/alphatr/acme-lego/common/config"
 	"github.com/alphatr/acme-lego/common/errors"
-	"github.com/alphatr/acme-lego/model/account"
 	"github.com/alphatr/acme-lego/model/client"
 )
 
 // Obtain 获取域名证书
 func Obtain(ctx *cli.Context) error {
-	acc, err := account.GetAccount(config.Config.RootDir)
-	if err != nil {
-		err := errors.NewError(errors.ConGetAccountErrno, err)
-		return cli.NewExitError(err.Error(), 301)
-	}
-
-	lego, err := client.NewClient(acc)
-	if err != nil {
-		err := errors.NewError(errors.ConInitClientErrno, err)
-		return cli.NewExitError(err.Error(), 302)
-	}
-
 	domain := ctx.String("domain")
 	httpPath := ctx.String("http-path")
 
+	groups := config.Config.DomainGroup
 	if len(domain) > 0 {
 		conf, ok := config.Config.DomainGroup[domain]
 		if !ok {
@@ -43,54 +30,89 @@ func Obtain(ctx *cli.Context) error {
 				KeyType:   []certcrypto.KeyType{certcrypto.RSA2048},
 				Challenge: "http-path",
 				Options:   map[string]string{"public": httpPath},
+				AcmeURL:   config.Config.AcmeURL,
+				Email:     config.Config.Email,
+				File:      config.Config.File,
 			}
 		}
 
-		if err := obtainDomain(domain, lego, conf); err != nil {
-			err := errors.NewError(errors.ConCertObtainDomainErrno, err, domain)
-			return cli.NewExitError(err.Error(), 304)
-		}
+		groups = map[string]*config.DomainConf{domain: conf}
+	}
 
-		bootstrap.Log.Infof("[success] request-certificate: %s\n", domain)
-		return nil
+	pool := newClientPool()
+	hooks := newHookRunner()
+	sum := newSummary("request-certificate")
+	for _, domain := range sortedDomains(groups) {
+		obtainDomain(domain, pool, hooks, groups[domain], sum)
 	}
 
-	for domain, conf := range config.Config.DomainGroup {
-		if err := obtainDomain(domain, lego, conf); err != nil {
-			err := errors.NewError(errors.ConCertObtainDomainErrno, err, domain)
-			return cli.NewExitError(err.Error(), 304)
-		}
+	hooks.finish()
 
-		bootstrap.Log.Infof("[success] request-certificate: %s\n", domain)
+	sum.print()
+	if sum.count(resultSuccess) > 0 {
+		if err := runAfterRenew(); err != nil {
+			return cli.NewExitError(err.Error(), 306)
+		}
 	}
 
-	return nil
+	return sum.exitError(304, 305)
 }
 
-func obtainDomain(domain string, cli *client.Client, conf *config.DomainConf) *errors.Error {
-	for _, keyType := range conf.KeyType {
-		if err := cli.SetupChallenge(conf.Challenge, domain, conf); err != nil {
-			return errors.NewError(errors.ConCertSetupChallengeErrno, err)
-		}
+func obtainDomain(domain string, pool *clientPool, hooks *hookRunner, conf *config.DomainConf, sum *summary) {
+	err := hooks.before(conf)
+	var lego *client.Client
+	if err == nil {
+		lego, err = pool.get(conf)
+	}
 
-		secret, errs := certcrypto.GeneratePrivateKey(keyType)
-		if errs != nil {
-			return errors.NewError(errors.ConCertGenerateKeyErrno, errs, keyType)
-		}
+	// 同一个域名组的证书类型使用相同的验证, 只检查一次
+	if err == nil {
+		err = selfCheck(domain, conf)
+	}
 
-		cert, err := cli.CertificateObtain(conf.Domains, secret)
+	for _, keyType := range conf.KeyType {
 		if err != nil {
-			return errors.NewError(errors.ConCertObtainErrno, err, domain, keyType)
+			sum.add(domain, keyType, resultFailed, errors.NewError(errors.ConCertObtainDomainErrno, err, domain))
+			continue
 		}
 
-		certPath := path.Join(config.Config.RootDir, "certificates", domain)
-		if err := checkFolder(certPath); err != nil {
-			return errors.NewError(errors.ConCertCheckFolderErrno, err, domain)
+		if err := obtainKeyType(domain, lego, conf, keyType); err != nil {
+			err := errors.NewError(errors.ConCertObtainDomainErrno, err, domain)
+			sum.add(domain, keyType, resultFailed, err)
+			continue
 		}
 
-		if err := saveCertRes(cert, certPath, keyType); err != nil {
-			return errors.NewError(errors.ConCertSaveCertErrno, err, domain, keyType)
+		if err := hooks.deploy(domain, conf, keyType); err != nil {
+			sum.add(domain, keyType, resultFailed, errors.NewError(errors.ConCertObtainDomainErrno, err, domain))
+			continue
 		}
+
+		sum.add(domain, keyType, resultSuccess, nil)
+	}
+}
+
+func obtainKeyType(domain string, cli *client.Client, conf *config.DomainConf, keyType certcrypto.KeyType) *errors.Error {
+	if err := cli.SetupChallenge(domain, conf); err != nil {
+		return errors.NewError(errors.ConCertSetupChallengeErrno, err)
+	}
+
+	secret, errs := certcrypto.GeneratePrivateKey(keyType)
+	if errs != nil {
+		return errors.NewError(errors.ConCertGenerateKeyErrno, errs, keyType)
+	}
+
+	cert, err := cli.CertificateObtain(conf.Domains, secret, conf.MustStaple)
+	if err != nil {
+		return errors.NewError(errors.ConCertObtainErrno, err, domain, keyType)
+	}
+
+	certPath := path.Join(config.Config.RootDir, "certificates", domain)
+	if err := checkFolder(certPath); err != nil {
+		return errors.NewError(errors.ConCertCheckFolderErrno, err, domain)
+	}
+
+	if err := saveCertRes(cert, certPath, keyType, conf); err != nil {
+		return errors.NewError(errors.ConCertSaveCertErrno, err, domain, keyType)
 	}
 
 	return nil
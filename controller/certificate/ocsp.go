@@ -0,0 +1,164 @@
+package certificate
+
+import (
+	"context"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/go-acme/lego/v3/certcrypto"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/alphatr/acme-lego/common"
+	"github.com/alphatr/acme-lego/common/bootstrap"
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+// OCSP 获取证书的 OCSP 响应并缓存到证书目录, 用于 nginx 和 HAProxy 的 OCSP Stapling
+func OCSP(ctx *cli.Context) error {
+	domain := ctx.String("domain")
+
+	groups := config.Config.DomainGroup
+	if len(domain) > 0 {
+		conf, ok := config.Config.DomainGroup[domain]
+		if !ok {
+			err := errors.NewError(errors.ConErrorParamErrno, nil, "domain")
+			return cli.NewExitError(err.Error(), 1001)
+		}
+
+		groups = map[string]*config.DomainConf{domain: conf}
+	}
+
+	sum := updateOCSPGroups(context.Background(), groups, ctx.Bool("force"))
+
+	sum.print()
+	if sum.count(resultSuccess) > 0 {
+		if err := runAfterRenew(); err != nil {
+			return cli.NewExitError(err.Error(), 1004)
+		}
+	}
+
+	return sum.exitError(1002, 1003)
+}
+
+func updateOCSPGroups(ctx context.Context, groups map[string]*config.DomainConf, force bool) *summary {
+	pool := newClientPool()
+	sum := newSummary("update-ocsp")
+	for _, domain := range sortedDomains(groups) {
+		if ctx.Err() != nil {
+			bootstrap.Log.Warnf("update-ocsp-interrupted: %s", domain)
+			break
+		}
+
+		conf := groups[domain]
+		for _, keyType := range conf.KeyType {
+			status, err := updateOCSP(domain, pool, conf, keyType, force)
+			if err != nil {
+				err = errors.NewError(errors.ConCertOCSPErrno, err, domain, keyTypeName(keyType))
+			}
+
+			sum.add(domain, keyType, status, err)
+		}
+	}
+
+	return sum
+}
+
+// updateOCSP 缓存的响应过了有效期的一半时重新获取, 没有证书或者证书不支持 OCSP 时跳过
+func updateOCSP(domain string, pool *clientPool, conf *config.DomainConf, keyType certcrypto.KeyType, force bool) (resultStatus, *errors.Error) {
+	certPath := path.Join(config.Config.RootDir, "certificates", domain)
+	files := generateFilePath(certPath, keyType)
+
+	content, errs := ioutil.ReadFile(files.Cert)
+	if os.IsNotExist(errs) {
+		bootstrap.Log.Debugf("ocsp-missing-cert: %s (%s)", domain, keyTypeName(keyType))
+		return resultSkipped, nil
+	}
+
+	if errs != nil {
+		return resultFailed, errors.NewError(errors.CommonFileReadErrno, errs, files.Cert)
+	}
+
+	cert, errs := certcrypto.ParsePEMCertificate(content)
+	if errs != nil {
+		return resultFailed, errors.NewError(errors.CommonParseCertificateErrno, errs, files.Cert)
+	}
+
+	if len(cert.OCSPServer) == 0 {
+		bootstrap.Log.Debugf("ocsp-not-supported: %s (%s)", domain, keyTypeName(keyType))
+		return resultSkipped, nil
+	}
+
+	if !force && ocspFresh(files.OCSP, cert) {
+		return resultSkipped, nil
+	}
+
+	lego, err := pool.get(conf)
+	if err != nil {
+		return resultFailed, err
+	}
+
+	raw, response, err := lego.CertificateOCSP(content)
+	if err != nil {
+		return resultFailed, err
+	}
+
+	// 不缓存非 good 的响应, 同时删除之前的响应, 避免继续提供已经失效的状态
+	if response.Status != ocsp.Good {
+		if errs := os.Remove(files.OCSP); errs != nil && !os.IsNotExist(errs) {
+			return resultFailed, errors.NewError(errors.CommonFileRenameErrno, errs, files.OCSP)
+		}
+
+		return resultFailed, errors.NewError(errors.ConCertOCSPStatusErrno, nil, ocspStatusName(response.Status))
+	}
+
+	if err := common.WriteFileAtomic(files.OCSP, raw, conf.File.FileMode, conf.File.UID, conf.File.GID); err != nil {
+		return resultFailed, err
+	}
+
+	// 新的响应同时复制到 deploy-to 目录, 其他没有变化的文件不会重新复制
+	if _, err := deployCopies(domain, conf, keyType); err != nil {
+		return resultFailed, err
+	}
+
+	bootstrap.Log.Debugf("ocsp-next-update: %s (%s) %s", domain, keyTypeName(keyType), response.NextUpdate.Format(time.RFC3339))
+	return resultSuccess, nil
+}
+
+// ocspFresh 缓存的响应属于当前证书, 并且没有超过有效期的一半
+func ocspFresh(file string, cert *x509.Certificate) bool {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return false
+	}
+
+	response, err := ocsp.ParseResponse(content, nil)
+	if err != nil || response.Status != ocsp.Good || response.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+		return false
+	}
+
+	// 没有 NextUpdate 时表示随时可能有新的状态, 每次都重新获取
+	if response.NextUpdate.IsZero() {
+		return false
+	}
+
+	refresh := response.ThisUpdate.Add(response.NextUpdate.Sub(response.ThisUpdate) / 2)
+	return time.Now().Before(refresh)
+}
+
+func ocspStatusName(status int) string {
+	switch status {
+	case ocsp.Good:
+		return "good"
+	case ocsp.Revoked:
+		return "revoked"
+	case ocsp.ServerFailed:
+		return "server-failed"
+	}
+
+	return "unknown"
+}
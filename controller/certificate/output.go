@@ -0,0 +1,90 @@
+package certificate
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"path"
+	"strings"
+
+	"github.com/go-acme/lego/v3/certcrypto"
+	"github.com/go-acme/lego/v3/certificate"
+	"software.sslmate.com/src/go-pkcs12"
+
+	"github.com/alphatr/acme-lego/common"
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+// writeOutputs 按配置在版本目录中生成额外格式的证书文件
+func writeOutputs(versionPath string, certRes *certificate.Resource, privateKey []byte, conf *config.DomainConf, keyType certcrypto.KeyType) *errors.Error {
+	if len(conf.Outputs) == 0 {
+		return nil
+	}
+
+	certs, errs := certcrypto.ParsePEMBundle(certRes.Certificate)
+	if errs != nil {
+		return errors.NewError(errors.CommonParseCertificateErrno, errs, certRes.Domain)
+	}
+
+	key, errs := certcrypto.ParsePEMPrivateKey(privateKey)
+	if errs != nil {
+		return errors.NewError(errors.CommonParsePrivateErrno, errs, certRes.Domain)
+	}
+
+	for _, item := range conf.Outputs {
+		name := outputName(item, keyType)
+		content, err := outputContent(item, certRes, certs, key, privateKey)
+		if err != nil {
+			return errors.NewError(errors.ConCertOutputErrno, err, item.Format, name)
+		}
+
+		file := path.Join(versionPath, name)
+		if err := common.WriteFileSync(file, content, item.Mode); err != nil {
+			return errors.NewError(errors.ConCertOutputErrno, err, item.Format, name)
+		}
+
+		if err := common.Chown(file, conf.File.UID, conf.File.GID); err != nil {
+			return errors.NewError(errors.ConCertOutputErrno, err, item.Format, name)
+		}
+	}
+
+	return nil
+}
+
+func outputName(item config.OutputConf, keyType certcrypto.KeyType) string {
+	return strings.Replace(item.Name, config.OutputKeyType, keyTypeName(keyType), -1)
+}
+
+func outputContent(item config.OutputConf, certRes *certificate.Resource, certs []*x509.Certificate, key crypto.PrivateKey, privateKey []byte) ([]byte, error) {
+	switch item.Format {
+	case "combined":
+		return bytes.Join([][]byte{certRes.Certificate, privateKey}, nil), nil
+	case "pkcs12":
+		return pkcs12.Encode(rand.Reader, key, certs[0], certs[1:], item.Password)
+	case "der":
+		return certs[0].Raw, nil
+	case "cert":
+		return encodeCertificates(certs[:1]), nil
+	case "chain":
+		// 证书中没有证书链时使用签发者证书
+		if len(certs) == 1 {
+			return certRes.IssuerCertificate, nil
+		}
+
+		return encodeCertificates(certs[1:]), nil
+	}
+
+	return nil, nil
+}
+
+func encodeCertificates(certs []*x509.Certificate) []byte {
+	content := []byte{}
+	for _, cert := range certs {
+		content = append(content, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+
+	return content
+}
@@ -1,8 +1,18 @@
 package certificate
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"net/url"
+	"os"
 	"path"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/go-acme/lego/v3/certcrypto"
@@ -12,27 +22,14 @@ import (
 	"github.com/alphatr/acme-lego/common/bootstrap"
 	"github.com/alphatr/acme-lego/common/config"
 	"github.com/alphatr/acme-lego/common/errors"
-	"github.com/alphatr/acme-lego/model/account"
 	"github.com/alphatr/acme-lego/model/client"
 )
 
 // Renew 续期域名证书
 func Renew(ctx *cli.Context) error {
-	acc, err := account.GetAccount(config.Config.RootDir)
-	if err != nil {
-		err := errors.NewError(errors.ConGetAccountErrno, err)
-		return cli.NewExitError(err.Error(), 401)
-	}
-
-	lego, err := client.NewClient(acc)
-	if err != nil {
-		err := errors.NewError(errors.ConInitClientErrno, err)
-		return cli.NewExitError(err.Error(), 402)
-	}
-
-	hasRenewSuccess := false
 	domain := ctx.String("domain")
 
+	groups := config.Config.DomainGroup
 	if len(domain) > 0 {
 		conf, ok := config.Config.DomainGroup[domain]
 		if !ok {
@@ -40,88 +37,332 @@ func Renew(ctx *cli.Context) error {
 			return cli.NewExitError(err.Error(), 403)
 		}
 
-		if err := renewDomain(domain, lego, conf); err != nil {
-			if err.Content.Errno == errors.ConCertRenewIgnoreErrno {
-				return nil
-			}
+		groups = map[string]*config.DomainConf{domain: conf}
+	}
 
-			err := errors.NewError(errors.ConCertRenewDomainErrno, err, domain)
-			return cli.NewExitError(err.Error(), 404)
-		}
+	sum := renewGroups(context.Background(), groups)
 
-		bootstrap.Log.Infof("[success] renew-certificate: %s\n", domain)
-	} else {
-		for domain, conf := range config.Config.DomainGroup {
-			if err := renewDomain(domain, lego, conf); err != nil {
-				if err.Content.Errno == errors.ConCertRenewIgnoreErrno {
-					continue
-				}
+	sum.print()
+	if sum.count(resultSuccess) > 0 {
+		if err := runAfterRenew(); err != nil {
+			return cli.NewExitError(err.Error(), 406)
+		}
+	}
 
-				err := errors.NewError(errors.ConCertRenewDomainErrno, err, domain)
-				return cli.NewExitError(err.Error(), 404)
-			}
+	return sum.exitError(404, 405)
+}
 
-			hasRenewSuccess = true
-			bootstrap.Log.Infof("[success] renew-certificate: %s\n", domain)
+// renewGroups 依次续期各个域名, ctx 取消后不再处理剩下的域名
+func renewGroups(ctx context.Context, groups map[string]*config.DomainConf) *summary {
+	pool := newClientPool()
+	hooks := newHookRunner()
+	sum := newSummary("renew-certificate")
+	for _, domain := range sortedDomains(groups) {
+		if ctx.Err() != nil {
+			bootstrap.Log.Warnf("renew-interrupted: %s", domain)
+			break
 		}
+
+		renewDomain(domain, pool, hooks, groups[domain], sum)
 	}
 
-	if len(config.Config.AfterRenew) > 0 && hasRenewSuccess {
-		result, err := common.RunCommand(config.Config.AfterRenew)
-		if err != nil {
-			return errors.NewError(errors.ConCertRunAfterRenewErrno, err)
-		}
+	hooks.finish()
 
-		if result != "" {
-			bootstrap.Log.Debugf("after-renew-output: %s", result)
-		}
+	return sum
+}
+
+func runAfterRenew() *errors.Error {
+	if len(config.Config.AfterRenew) == 0 {
+		return nil
+	}
+
+	result, err := common.RunCommand(config.Config.AfterRenew)
+	if err != nil {
+		return errors.NewError(errors.ConCertRunAfterRenewErrno, err)
+	}
+
+	if result != "" {
+		bootstrap.Log.Debugf("after-renew-output: %s", result)
 	}
 
 	return nil
 }
 
-func renewDomain(domain string, cli *client.Client, conf *config.DomainConf) *errors.Error {
-	for _, keyType := range conf.KeyType {
-		if err := cli.SetupChallenge(conf.Challenge, domain, conf); err != nil {
-			return errors.NewError(errors.ConCertSetupChallengeErrno, err)
+func renewDomain(domain string, pool *clientPool, hooks *hookRunner, conf *config.DomainConf, sum *summary) {
+	manual := requiresManual(conf)
+
+	// 同一个域名组的证书类型使用相同的验证, 第一次需要请求 CA 时检查一次
+	checked := false
+	var checkErr *errors.Error
+	groupCheck := func() *errors.Error {
+		if !checked {
+			checked = true
+			checkErr = selfCheck(domain, conf)
 		}
 
+		return checkErr
+	}
+
+	for _, keyType := range conf.KeyType {
 		certPath := path.Join(config.Config.RootDir, "certificates", domain)
 		files := generateFilePath(certPath, keyType)
 
-		content, errs := ioutil.ReadFile(files.Cert)
-		if errs != nil {
-			return errors.NewError(errors.CommonFileReadErrno, errs, files.Cert)
+		// 新增的证书类型还没有证书文件, 作为新证书申请
+		if _, err := os.Stat(files.Cert); os.IsNotExist(err) {
+			if manual {
+				sum.add(domain, keyType, resultManual, errors.NewError(errors.ConCertRequireManualErrno, nil, domain))
+				continue
+			}
+
+			bootstrap.Log.Infof("obtain-missing-cert: %s (%s)", domain, keyTypeName(keyType))
+
+			if err := hooks.before(conf); err != nil {
+				sum.add(domain, keyType, resultFailed, errors.NewError(errors.ConCertObtainDomainErrno, err, domain))
+				continue
+			}
+
+			lego, err := pool.get(conf)
+			if err != nil {
+				sum.add(domain, keyType, resultFailed, errors.NewError(errors.ConCertObtainDomainErrno, err, domain))
+				continue
+			}
+
+			if err := groupCheck(); err != nil {
+				sum.add(domain, keyType, resultFailed, errors.NewError(errors.ConCertObtainDomainErrno, err, domain))
+				continue
+			}
+
+			if err := obtainKeyType(domain, lego, conf, keyType); err != nil {
+				err := errors.NewError(errors.ConCertObtainDomainErrno, err, domain)
+				sum.add(domain, keyType, resultFailed, err)
+				continue
+			}
+
+			if err := hooks.deploy(domain, conf, keyType); err != nil {
+				sum.add(domain, keyType, resultFailed, errors.NewError(errors.ConCertObtainDomainErrno, err, domain))
+				continue
+			}
+
+			sum.add(domain, keyType, resultSuccess, nil)
+			continue
+		}
+
+		cert, err := loadCertificate(files.Cert)
+		if err != nil {
+			err := errors.NewError(errors.ConCertRenewDomainErrno, err, domain)
+			sum.add(domain, keyType, resultFailed, err)
+			continue
 		}
 
-		cert, errs := certcrypto.ParsePEMCertificate(content)
-		if errs != nil {
-			return errors.NewError(errors.CommonParseCertificateErrno, errs, domain, keyType)
+		reason, newKey := renewReason(cert, files, conf, keyType)
+		if reason == "" {
+			// 上次复制失败或者目标文件被修改时重新复制
+			if err := hooks.redeploy(domain, conf, keyType); err != nil {
+				sum.add(domain, keyType, resultFailed, errors.NewError(errors.ConCertRenewDomainErrno, err, domain))
+				continue
+			}
+
+			bootstrap.Log.Debugf("ignore-cert-renew: %s (%s)", domain, keyTypeName(keyType))
+			sum.add(domain, keyType, resultSkipped, nil)
+			continue
 		}
 
-		if cert.NotAfter.After(time.Now().Add(config.Config.Expires)) {
-			bootstrap.Log.Debugf("ignore-cert-renew: %s", domain)
-			return errors.NewError(errors.ConCertRenewIgnoreErrno, nil)
+		if manual {
+			sum.add(domain, keyType, resultManual, errors.NewError(errors.ConCertRequireManualErrno, nil, domain))
+			continue
 		}
 
-		privateKey, err := common.LoadPrivateKey(files.Prev)
-		if err != nil {
-			return errors.NewError(errors.ConCertLoadPrivateErrno, err, domain, keyType)
+		if err := hooks.before(conf); err != nil {
+			sum.add(domain, keyType, resultFailed, errors.NewError(errors.ConCertRenewDomainErrno, err, domain))
+			continue
 		}
 
-		newCert, err := cli.CertificateObtain(conf.Domains, privateKey)
+		lego, err := pool.get(conf)
 		if err != nil {
-			return errors.NewError(errors.ConCertObtainErrno, err, domain, keyType)
+			sum.add(domain, keyType, resultFailed, errors.NewError(errors.ConCertRenewDomainErrno, err, domain))
+			continue
+		}
+
+		if err := groupCheck(); err != nil {
+			sum.add(domain, keyType, resultFailed, errors.NewError(errors.ConCertRenewDomainErrno, err, domain))
+			continue
+		}
+
+		bootstrap.Log.Infof("renew-cert: %s (%s), reason: %s", domain, keyTypeName(keyType), reason)
+
+		renew := renewKeyType
+		if newKey {
+			renew = obtainKeyType
+		}
+
+		if err := renew(domain, lego, conf, keyType); err != nil {
+			err := errors.NewError(errors.ConCertRenewDomainErrno, err, domain)
+			sum.add(domain, keyType, resultFailed, err)
+			continue
+		}
+
+		if err := hooks.deploy(domain, conf, keyType); err != nil {
+			sum.add(domain, keyType, resultFailed, errors.NewError(errors.ConCertRenewDomainErrno, err, domain))
+			continue
+		}
+
+		sum.add(domain, keyType, resultSuccess, nil)
+	}
+}
+
+// renewReason 返回证书需要续签的原因, 不需要续签时返回空; 密钥类型变化时需要生成新的私钥
+func renewReason(cert *x509.Certificate, files *certFilePath, conf *config.DomainConf, keyType certcrypto.KeyType) (string, bool) {
+	if added, removed := diffDomains(cert.DNSNames, conf.Domains); len(added) > 0 || len(removed) > 0 {
+		changes := []string{}
+		for _, item := range added {
+			changes = append(changes, "+"+item)
 		}
 
-		if err := checkFolder(certPath); err != nil {
-			return errors.NewError(errors.ConCertCheckFolderErrno, err, domain)
+		for _, item := range removed {
+			changes = append(changes, "-"+item)
 		}
 
-		if err := saveCertRes(newCert, certPath, keyType); err != nil {
-			return errors.NewError(errors.ConCertSaveCertErrno, err, domain, keyType)
+		return fmt.Sprintf("domains-changed(%s)", strings.Join(changes, ", ")), false
+	}
+
+	if current := certKeyType(cert); current != keyType {
+		return fmt.Sprintf("key-type-changed(%s -> %s)", common.DefaultString(keyTypeName(current), "unknown"), keyTypeName(keyType)), true
+	}
+
+	meta := readCertMeta(files.Meta)
+	if issued, configured := urlHost(meta.CertURL), urlHost(conf.AcmeURL); issued != "" && issued != configured {
+		return fmt.Sprintf("issuer-changed(%s -> %s)", issued, configured), false
+	}
+
+	// 旧版本的元数据中没有 must-staple, 无法确定时等到过期前再续签
+	if meta.MustStaple != nil && *meta.MustStaple != conf.MustStaple {
+		return fmt.Sprintf("must-staple-changed(%t -> %t)", *meta.MustStaple, conf.MustStaple), false
+	}
+
+	if !cert.NotAfter.After(time.Now().Add(config.Config.Expires)) {
+		return fmt.Sprintf("expiring(%s)", cert.NotAfter.Format(time.RFC3339)), false
+	}
+
+	return "", false
+}
+
+// diffDomains 比较证书中的域名和配置的域名, 忽略大小写和顺序
+func diffDomains(issued []string, configured []string) ([]string, []string) {
+	issuedSet := map[string]bool{}
+	for _, item := range issued {
+		issuedSet[strings.ToLower(item)] = true
+	}
+
+	configuredSet := map[string]bool{}
+	added := []string{}
+	for _, item := range configured {
+		item = strings.ToLower(item)
+		configuredSet[item] = true
+		if !issuedSet[item] {
+			added = append(added, item)
+		}
+	}
+
+	removed := []string{}
+	for _, item := range issued {
+		if !configuredSet[strings.ToLower(item)] {
+			removed = append(removed, item)
 		}
 	}
 
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// certKeyType 证书公钥对应的密钥类型, 无法识别时返回空
+func certKeyType(cert *x509.Certificate) certcrypto.KeyType {
+	switch key := cert.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		switch key.Curve.Params().BitSize {
+		case 256:
+			return certcrypto.EC256
+		case 384:
+			return certcrypto.EC384
+		}
+	case *rsa.PublicKey:
+		switch key.N.BitLen() {
+		case 2048:
+			return certcrypto.RSA2048
+		case 4096:
+			return certcrypto.RSA4096
+		case 8192:
+			return certcrypto.RSA8192
+		}
+	}
+
+	return ""
+}
+
+// readCertMeta 读取证书元数据, 读取失败时返回空的元数据
+func readCertMeta(metaFile string) *certMeta {
+	meta := &certMeta{}
+	content, err := ioutil.ReadFile(metaFile)
+	if err != nil {
+		return meta
+	}
+
+	if err := json.Unmarshal(content, meta); err != nil {
+		return &certMeta{}
+	}
+
+	return meta
+}
+
+func urlHost(input string) string {
+	result, err := url.Parse(input)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(result.Host)
+}
+
+// requiresManual 使用 dns-manual 验证的域名只能在终端中续签
+func requiresManual(conf *config.DomainConf) bool {
+	if common.StdinIsTerminal() {
+		return false
+	}
+
+	for _, identifier := range conf.Domains {
+		if conf.ChallengeFor(identifier) == "dns-manual" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func renewKeyType(domain string, cli *client.Client, conf *config.DomainConf, keyType certcrypto.KeyType) *errors.Error {
+	if err := cli.SetupChallenge(domain, conf); err != nil {
+		return errors.NewError(errors.ConCertSetupChallengeErrno, err)
+	}
+
+	certPath := path.Join(config.Config.RootDir, "certificates", domain)
+	files := generateFilePath(certPath, keyType)
+
+	privateKey, err := common.LoadPrivateKey(files.Prev)
+	if err != nil {
+		return errors.NewError(errors.ConCertLoadPrivateErrno, err, domain, keyType)
+	}
+
+	newCert, err := cli.CertificateObtain(conf.Domains, privateKey, conf.MustStaple)
+	if err != nil {
+		return errors.NewError(errors.ConCertObtainErrno, err, domain, keyType)
+	}
+
+	if err := checkFolder(certPath); err != nil {
+		return errors.NewError(errors.ConCertCheckFolderErrno, err, domain)
+	}
+
+	if err := saveCertRes(newCert, certPath, keyType, conf); err != nil {
+		return errors.NewError(errors.ConCertSaveCertErrno, err, domain, keyType)
+	}
+
 	return nil
 }
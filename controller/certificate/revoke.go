@@ -0,0 +1,104 @@
+package certificate
+
+import (
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-acme/lego/v3/certcrypto"
+	"github.com/urfave/cli/v2"
+
+	"github.com/alphatr/acme-lego/common/bootstrap"
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+	"github.com/alphatr/acme-lego/model/client"
+)
+
+// RFC 5280 5.3.1 定义的吊销原因, 不包括 ACME CA 不接受的 certificateHold 和 removeFromCRL
+var revokeReasonMap = map[string]uint{
+	"unspecified":          0,
+	"keycompromise":        1,
+	"cacompromise":         2,
+	"affiliationchanged":   3,
+	"superseded":           4,
+	"cessationofoperation": 5,
+	"privilegewithdrawn":   9,
+	"aacompromise":         10,
+}
+
+// Revoke 吊销域名证书
+func Revoke(ctx *cli.Context) error {
+	domain := strings.ToLower(ctx.String("domain"))
+	if len(domain) == 0 {
+		err := errors.NewError(errors.ConRequireParamErrno, nil, "domain")
+		return cli.NewExitError(err.Error(), 501)
+	}
+
+	var reason *uint
+	if input := ctx.String("reason"); len(input) > 0 {
+		code, ok := revokeReasonMap[strings.ToLower(input)]
+		if !ok {
+			err := errors.NewError(errors.ConErrorParamErrno, nil, "reason")
+			return cli.NewExitError(err.Error(), 502)
+		}
+
+		reason = &code
+	}
+
+	conf, ok := config.Config.DomainGroup[domain]
+	if !ok {
+		conf = &config.DomainConf{AcmeURL: config.Config.AcmeURL, Email: config.Config.Email}
+	}
+
+	keyTypes := config.KeyTypeList([]string{ctx.String("key-type")})
+	if len(ctx.String("key-type")) == 0 {
+		if !ok {
+			err := errors.NewError(errors.ConRequireParamErrno, nil, "key-type")
+			return cli.NewExitError(err.Error(), 501)
+		}
+
+		keyTypes = conf.KeyType
+	} else if len(keyTypes) == 0 {
+		err := errors.NewError(errors.ConErrorParamErrno, nil, "key-type")
+		return cli.NewExitError(err.Error(), 502)
+	}
+
+	lego, err := newClient(conf)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 503)
+	}
+
+	for _, keyType := range keyTypes {
+		if err := revokeDomain(domain, lego, keyType, reason); err != nil {
+			return cli.NewExitError(err.Error(), 505)
+		}
+
+		bootstrap.Log.Infof("[success] revoke-certificate: %s (%s)\n", domain, keyType)
+	}
+
+	return nil
+}
+
+func revokeDomain(domain string, cli *client.Client, keyType certcrypto.KeyType, reason *uint) *errors.Error {
+	certPath := path.Join(config.Config.RootDir, "certificates", domain)
+	files := generateFilePath(certPath, keyType)
+
+	content, errs := ioutil.ReadFile(files.Cert)
+	if errs != nil {
+		err := errors.NewError(errors.CommonFileReadErrno, errs, files.Cert)
+		return errors.NewError(errors.ConCertRevokeErrno, err, domain, keyType)
+	}
+
+	if err := cli.CertificateRevoke(content, reason); err != nil {
+		return errors.NewError(errors.ConCertRevokeErrno, err, domain, keyType)
+	}
+
+	// 吊销后的证书移出证书目录, 避免被续期时继续使用原私钥
+	archivePath := path.Join(certPath, "revoked", time.Now().Format("20060102150405"))
+	if err := archiveCertFiles(certPath, keyType, archivePath); err != nil {
+		return errors.NewError(errors.ConCertArchiveErrno, err, domain, keyType)
+	}
+
+	return nil
+}
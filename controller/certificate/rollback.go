@@ -0,0 +1,103 @@
+package certificate
+
+import (
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/go-acme/lego/v3/certcrypto"
+	"github.com/urfave/cli/v2"
+
+	"github.com/alphatr/acme-lego/common/bootstrap"
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+// Rollback 回滚域名证书到上一个存档版本
+func Rollback(ctx *cli.Context) error {
+	domain := strings.ToLower(ctx.String("domain"))
+	if len(domain) == 0 {
+		err := errors.NewError(errors.ConRequireParamErrno, nil, "domain")
+		return cli.NewExitError(err.Error(), 901)
+	}
+
+	conf, ok := config.Config.DomainGroup[domain]
+	keyTypes := config.KeyTypeList([]string{ctx.String("key-type")})
+	if len(ctx.String("key-type")) == 0 {
+		if !ok {
+			err := errors.NewError(errors.ConRequireParamErrno, nil, "key-type")
+			return cli.NewExitError(err.Error(), 901)
+		}
+
+		keyTypes = conf.KeyType
+	} else if len(keyTypes) == 0 {
+		err := errors.NewError(errors.ConErrorParamErrno, nil, "key-type")
+		return cli.NewExitError(err.Error(), 902)
+	}
+
+	hooks := newHookRunner()
+	for _, keyType := range keyTypes {
+		if err := rollbackKeyType(domain, keyType); err != nil {
+			return cli.NewExitError(err.Error(), 903)
+		}
+
+		if !ok {
+			continue
+		}
+
+		if err := hooks.deploy(domain, conf, keyType); err != nil {
+			return cli.NewExitError(err.Error(), 903)
+		}
+	}
+
+	if err := runAfterRenew(); err != nil {
+		return cli.NewExitError(err.Error(), 904)
+	}
+
+	return nil
+}
+
+func rollbackKeyType(domain string, keyType certcrypto.KeyType) *errors.Error {
+	certPath := path.Join(config.Config.RootDir, "certificates", domain)
+
+	// 没有 current 软链接时证书还没有写入过存档 (旧版本保存的证书), 没有可以回滚的版本
+	link := generateFilePath(certPath, keyType).Current
+	if _, errs := os.Lstat(link); os.IsNotExist(errs) {
+		err := errors.NewError(errors.ConCertNoPreviousErrno, nil, link)
+		return errors.NewError(errors.ConCertRollbackErrno, err, domain, keyTypeName(keyType))
+	}
+
+	current, err := currentVersion(certPath, keyType)
+	if err != nil {
+		return errors.NewError(errors.ConCertRollbackErrno, err, domain, keyTypeName(keyType))
+	}
+
+	dir := archiveDir(certPath)
+	versions, err := archiveVersions(dir)
+	if err != nil {
+		return errors.NewError(errors.ConCertRollbackErrno, err, domain, keyTypeName(keyType))
+	}
+
+	// 不同证书类型共用版本号, 找到当前版本之前最近的同类型证书
+	for index := len(versions) - 1; index >= 0; index-- {
+		if versions[index] >= current {
+			continue
+		}
+
+		versionPath := path.Join(dir, strconv.Itoa(versions[index]))
+		if _, err := os.Stat(generateFilePath(versionPath, keyType).Cert); err != nil {
+			continue
+		}
+
+		if err := switchVersion(certPath, keyType, versionPath); err != nil {
+			return errors.NewError(errors.ConCertRollbackErrno, err, domain, keyTypeName(keyType))
+		}
+
+		bootstrap.Log.Infof("[success] rollback-certificate: %s (%s) %d -> %d\n", domain, keyTypeName(keyType), current, versions[index])
+		return nil
+	}
+
+	err = errors.NewError(errors.ConCertNoPreviousErrno, nil, path.Join(dir, strconv.Itoa(current)))
+	return errors.NewError(errors.ConCertRollbackErrno, err, domain, keyTypeName(keyType))
+}
@@ -0,0 +1,130 @@
+package certificate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/alphatr/acme-lego/common"
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+type certStatus struct {
+	Domain    string     `json:"domain"`
+	KeyType   string     `json:"key_type"`
+	SANs      []string   `json:"sans"`
+	Issuer    string     `json:"issuer"`
+	NotBefore *time.Time `json:"not_before"`
+	NotAfter  *time.Time `json:"not_after"`
+	DaysLeft  int        `json:"days_left"`
+	RenewDue  bool       `json:"renew_due"`
+	Missing   []string   `json:"missing"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// Status 输出全部证书的状态
+func Status(ctx *cli.Context) error {
+	domains := []string{}
+	if domain := strings.ToLower(ctx.String("domain")); len(domain) > 0 {
+		if _, ok := config.Config.DomainGroup[domain]; !ok {
+			err := errors.NewError(errors.ConErrorParamErrno, nil, "domain")
+			return cli.NewExitError(err.Error(), 601)
+		}
+
+		domains = append(domains, domain)
+	} else {
+		for domain := range config.Config.DomainGroup {
+			domains = append(domains, domain)
+		}
+
+		sort.Strings(domains)
+	}
+
+	list := []*certStatus{}
+	for _, domain := range domains {
+		list = append(list, domainStatus(domain, config.Config.DomainGroup[domain])...)
+	}
+
+	if ctx.Bool("json") {
+		content, err := json.MarshalIndent(list, "", "    ")
+		if err != nil {
+			err := errors.NewError(errors.CommonJSONMarshalErrno, err)
+			return cli.NewExitError(err.Error(), 602)
+		}
+
+		fmt.Fprintln(ctx.App.Writer, string(content))
+		return nil
+	}
+
+	printStatusTable(ctx, list)
+	return nil
+}
+
+func domainStatus(domain string, conf *config.DomainConf) []*certStatus {
+	result := []*certStatus{}
+	certPath := path.Join(config.Config.RootDir, "certificates", domain)
+
+	for _, keyType := range conf.KeyType {
+		files := generateFilePath(certPath, keyType)
+		status := &certStatus{Domain: domain, KeyType: keyTypeName(keyType), SANs: []string{}, Missing: []string{}}
+		result = append(result, status)
+
+		for _, file := range []string{files.Cert, files.Prev, files.Issuer, files.Meta} {
+			if _, err := os.Stat(file); os.IsNotExist(err) {
+				status.Missing = append(status.Missing, path.Base(file))
+			}
+		}
+
+		cert, err := loadCertificate(files.Cert)
+		if err != nil {
+			status.RenewDue = true
+			if _, errs := os.Stat(files.Cert); !os.IsNotExist(errs) {
+				status.Error = err.Error()
+			}
+
+			continue
+		}
+
+		status.SANs = cert.DNSNames
+		status.Issuer = cert.Issuer.CommonName
+		status.NotBefore = &cert.NotBefore
+		status.NotAfter = &cert.NotAfter
+		status.DaysLeft = int(time.Until(cert.NotAfter).Hours() / 24)
+		status.RenewDue = !cert.NotAfter.After(time.Now().Add(config.Config.Expires))
+	}
+
+	return result
+}
+
+func printStatusTable(ctx *cli.Context, list []*certStatus) {
+	writer := tabwriter.NewWriter(ctx.App.Writer, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "DOMAIN\tKEY-TYPE\tSANS\tISSUER\tNOT-BEFORE\tNOT-AFTER\tDAYS-LEFT\tRENEW-DUE\tMISSING")
+
+	for _, item := range list {
+		notBefore, notAfter, daysLeft := "-", "-", "-"
+		if item.NotAfter != nil {
+			notBefore = item.NotBefore.Format("2006-01-02")
+			notAfter = item.NotAfter.Format("2006-01-02")
+			daysLeft = fmt.Sprintf("%d", item.DaysLeft)
+		}
+
+		missing := common.DefaultString(strings.Join(item.Missing, ","), "-")
+		if len(item.Error) > 0 {
+			missing = item.Error
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%t\t%s\n", item.Domain, item.KeyType,
+			common.DefaultString(strings.Join(item.SANs, ","), "-"), common.DefaultString(item.Issuer, "-"),
+			notBefore, notAfter, daysLeft, item.RenewDue, missing)
+	}
+
+	writer.Flush()
+}
@@ -0,0 +1,108 @@
+package certificate
+
+import (
+	"sort"
+
+	"github.com/go-acme/lego/v3/certcrypto"
+	"github.com/urfave/cli/v2"
+
+	"github.com/alphatr/acme-lego/common/bootstrap"
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+type resultStatus string
+
+const (
+	resultSuccess resultStatus = "success"
+	resultSkipped resultStatus = "skipped"
+	resultFailed  resultStatus = "failed"
+	resultManual  resultStatus = "manual"
+)
+
+type certResult struct {
+	Domain  string
+	KeyType certcrypto.KeyType
+	Status  resultStatus
+	Error   *errors.Error
+}
+
+// summary 记录每个域名和证书类型的处理结果
+type summary struct {
+	action  string
+	results []*certResult
+}
+
+func newSummary(action string) *summary {
+	return &summary{action: action, results: []*certResult{}}
+}
+
+func (sum *summary) add(domain string, keyType certcrypto.KeyType, status resultStatus, err *errors.Error) {
+	sum.results = append(sum.results, &certResult{Domain: domain, KeyType: keyType, Status: status, Error: err})
+
+	switch status {
+	case resultSuccess:
+		bootstrap.Log.Infof("[success] %s: %s (%s)\n", sum.action, domain, keyTypeName(keyType))
+	case resultSkipped:
+		bootstrap.Log.Debugf("[skipped] %s: %s (%s)", sum.action, domain, keyTypeName(keyType))
+	case resultFailed:
+		bootstrap.Log.Errorf("[failed] %s: %s (%s) %s", sum.action, domain, keyTypeName(keyType), err)
+	case resultManual:
+		bootstrap.Log.Warnf("[manual] %s: %s (%s) requires manual action", sum.action, domain, keyTypeName(keyType))
+	}
+}
+
+func (sum *summary) count(status resultStatus) int {
+	count := 0
+	for _, item := range sum.results {
+		if item.Status == status {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (sum *summary) print() {
+	bootstrap.Log.Infof("%s-summary: success %d, skipped %d, failed %d, manual %d", sum.action,
+		sum.count(resultSuccess), sum.count(resultSkipped), sum.count(resultFailed), sum.count(resultManual))
+
+	for _, item := range sum.results {
+		if item.Status == resultManual {
+			bootstrap.Log.Warnf("%s-%s: %s (%s) requires manual action", sum.action, item.Status, item.Domain, keyTypeName(item.KeyType))
+			continue
+		}
+
+		if item.Status == resultFailed {
+			bootstrap.Log.Warnf("%s-%s: %s (%s)", sum.action, item.Status, item.Domain, keyTypeName(item.KeyType))
+			continue
+		}
+
+		bootstrap.Log.Infof("%s-%s: %s (%s)", sum.action, item.Status, item.Domain, keyTypeName(item.KeyType))
+	}
+}
+
+// exitError 全部失败时返回 failedCode, 部分失败时返回 partialCode, 需要手动处理的也算作失败
+func (sum *summary) exitError(failedCode int, partialCode int) error {
+	failed := sum.count(resultFailed) + sum.count(resultManual)
+	if failed == 0 {
+		return nil
+	}
+
+	err := errors.NewError(errors.ConCertSummaryFailedErrno, nil, sum.action, failed)
+	if failed == len(sum.results) {
+		return cli.NewExitError(err.Error(), failedCode)
+	}
+
+	return cli.NewExitError(err.Error(), partialCode)
+}
+
+func sortedDomains(groups map[string]*config.DomainConf) []string {
+	domains := []string{}
+	for domain := range groups {
+		domains = append(domains, domain)
+	}
+
+	sort.Strings(domains)
+	return domains
+}
@@ -3,8 +3,12 @@ module github.com/alphatr/acme-lego
 go 1.13
 
 require (
+	github.com/cpu/goacmedns v0.0.2
 	github.com/go-acme/lego/v3 v3.9.0
+	github.com/miekg/dns v1.1.27
 	github.com/pelletier/go-toml v1.8.1
 	github.com/sirupsen/logrus v1.7.0
 	github.com/urfave/cli/v2 v2.3.0
+	golang.org/x/crypto v0.0.0-20200302210943-78000ba7a073
+	software.sslmate.com/src/go-pkcs12 v0.0.0-20200830195227-52f69702a001
 )
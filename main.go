@@ -40,6 +40,23 @@ func main() {
 					Name:  "mail",
 					Usage: "account email",
 				},
+				&cli.StringFlag{
+					Name:  "acme-url",
+					Usage: "ACME directory URL or shortcut, default acme-url in config",
+				},
+				&cli.StringFlag{
+					Name:    "domain",
+					Aliases: []string{"d"},
+					Usage:   "register the account used by the domain group",
+				},
+				&cli.StringFlag{
+					Name:  "eab-kid",
+					Usage: "external account binding key identifier",
+				},
+				&cli.StringFlag{
+					Name:  "eab-hmac-key",
+					Usage: "external account binding base64url encoded HMAC key",
+				},
 			},
 			Before: beforeCommand,
 		},
@@ -75,6 +92,120 @@ func main() {
 			},
 			Before: beforeCommand,
 		},
+
+		{
+			Name:   "daemon",
+			Usage:  "run in background and renew certificates periodically",
+			Action: certificate.Daemon,
+			Flags: []cli.Flag{
+				&cli.DurationFlag{
+					Name:  "interval",
+					Usage: "check interval, override renew-interval in config",
+				},
+				&cli.DurationFlag{
+					Name:  "jitter",
+					Usage: "max random delay added to the interval, override renew-jitter in config",
+				},
+			},
+			Before: beforeCommand,
+		},
+
+		{
+			Name:   "ocsp",
+			Usage:  "fetch and cache OCSP responses for stapling",
+			Action: certificate.OCSP,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "domain",
+					Aliases: []string{"d"},
+					Usage:   "certificate domain",
+				},
+				&cli.BoolFlag{
+					Name:  "force",
+					Usage: "fetch even if the cached response is still fresh",
+				},
+			},
+			Before: beforeCommand,
+		},
+
+		{
+			Name:    "status",
+			Aliases: []string{"list"},
+			Usage:   "show status of all managed certificates",
+			Action:  certificate.Status,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "domain",
+					Aliases: []string{"d"},
+					Usage:   "certificate domain",
+				},
+				&cli.BoolFlag{
+					Name:  "json",
+					Usage: "output as json",
+				},
+			},
+			Before: beforeCommand,
+		},
+
+		{
+			Name:   "check",
+			Usage:  "check the http challenges are reachable without requesting the CA",
+			Action: certificate.Check,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "domain",
+					Aliases: []string{"d"},
+					Usage:   "certificate domain",
+				},
+			},
+			Before: beforeCommand,
+		},
+
+		{
+			Name:   "check-config",
+			Usage:  "validate the config file and report every problem",
+			Action: certificate.CheckConfig,
+		},
+
+		{
+			Name:   "revoke",
+			Usage:  "revoke certificate",
+			Action: certificate.Revoke,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "domain",
+					Aliases: []string{"d"},
+					Usage:   "certificate domain",
+				},
+				&cli.StringFlag{
+					Name:  "key-type",
+					Usage: "certificate key type, default all key types of the domain group",
+				},
+				&cli.StringFlag{
+					Name:  "reason",
+					Usage: "revocation reason, e.g. keyCompromise, superseded, cessationOfOperation",
+				},
+			},
+			Before: beforeCommand,
+		},
+
+		{
+			Name:   "rollback",
+			Usage:  "restore the previous archived certificate",
+			Action: certificate.Rollback,
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "domain",
+					Aliases: []string{"d"},
+					Usage:   "certificate domain",
+				},
+				&cli.StringFlag{
+					Name:  "key-type",
+					Usage: "certificate key type, default all key types of the domain group",
+				},
+			},
+			Before: beforeCommand,
+		},
 	}
 
 	app.Flags = []cli.Flag{
@@ -114,6 +245,11 @@ func versionPrinter(ctx *cli.Context) {
 }
 
 func (ins *errWriter) Write(input []byte) (int, error) {
+	// 配置加载失败时还没有初始化 Log
+	if bootstrap.Log == nil {
+		return os.Stderr.Write(input)
+	}
+
 	bootstrap.Log.Error(string(input))
 	return len(input), nil
 }
@@ -19,6 +19,8 @@ import (
 type Account struct {
 	Email        string                 `json:"email"`
 	Registration *registration.Resource `json:"registration"`
+	EABKid       string                 `json:"eab_kid,omitempty"`
+	Server       string                 `json:"server"`
 	secret       *ecdsa.PrivateKey
 	path         string
 }
@@ -73,7 +75,7 @@ func (acc *Account) saveAccountPrivateKey() *errors.Error {
 
 	accKeyPath := path.Join(acc.path, "account.key")
 	block := pem.Block{Type: "EC PRIVATE KEY", Bytes: bytes}
-	output, err := os.Create(accKeyPath)
+	output, err := os.OpenFile(accKeyPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
 		return errors.NewError(errors.CommonFileCreateErrno, err, accKeyPath)
 	}
@@ -10,9 +10,9 @@ import (
 	"github.com/alphatr/acme-lego/common/errors"
 )
 
-// CreateAccount 创建用户账户
-func CreateAccount(email string, rootDir string) (*Account, *errors.Error) {
-	accountPath := path.Join(rootDir, "account")
+// CreateAccount 创建 CA 对应的用户账户
+func CreateAccount(email string, rootDir string, server string) (*Account, *errors.Error) {
+	accountPath := AccountPath(rootDir, server, email)
 
 	if err := os.MkdirAll(accountPath, 0755); err != nil {
 		return nil, errors.NewError(errors.CommonMakeDirErrno, err, accountPath)
@@ -33,5 +33,5 @@ func CreateAccount(email string, rootDir string) (*Account, *errors.Error) {
 		return nil, errors.NewError(errors.ModelAccGenerateKeyErrno, err)
 	}
 
-	return &Account{Email: email, secret: secret, path: accountPath}, nil
+	return &Account{Email: email, Server: server, secret: secret, path: accountPath}, nil
 }
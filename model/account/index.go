@@ -4,17 +4,52 @@ import (
 	"crypto/ecdsa"
 	"encoding/json"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/alphatr/acme-lego/common"
 	"github.com/alphatr/acme-lego/common/errors"
 )
 
-// GetAccount 获取用户账户
-func GetAccount(rootDir string) (*Account, *errors.Error) {
-	accDir := path.Join(rootDir, "account")
+// GetAccount 获取 CA 和邮箱对应的用户账户, 兼容旧版本的 account 目录
+func GetAccount(rootDir string, server string, email string) (*Account, *errors.Error) {
+	acc, err := loadAccount(AccountPath(rootDir, server, email))
+	if err == nil {
+		acc.Server = server
+		return acc, nil
+	}
+
+	if err.Content.Errno != errors.CommonFileNotExistErrno {
+		return nil, err
+	}
+
+	// 旧版本只有一个账户, 只在注册的 CA 和邮箱一致时使用
+	legacy, errs := loadAccount(path.Join(rootDir, "account"))
+	if errs != nil || legacy.Email != email || !sameServer(legacy, server) {
+		return nil, err
+	}
+
+	legacy.Server = server
+	return legacy, nil
+}
+
+// AccountPath 返回账户目录 accounts/<host>_<path>/<email>, 同一个 host 上的不同 ACME 目录使用不同的账户
+func AccountPath(rootDir string, server string, email string) string {
+	name := server
+	if parsed, err := url.Parse(server); err == nil && len(parsed.Host) > 0 {
+		name = parsed.Host + strings.TrimSuffix(parsed.EscapedPath(), "/")
+	}
 
+	return path.Join(rootDir, "accounts", escapeName(name), escapeName(common.DefaultString(email, "default")))
+}
+
+func escapeName(name string) string {
+	return strings.NewReplacer(":", "_", "/", "_", "\\", "_").Replace(name)
+}
+
+func loadAccount(accDir string) (*Account, *errors.Error) {
 	keyPath := path.Join(accDir, "account.key")
 	if _, err := os.Stat(keyPath); os.IsNotExist(err) {
 		return nil, errors.NewError(errors.CommonFileNotExistErrno, nil, keyPath)
@@ -49,3 +84,21 @@ func GetAccount(rootDir string) (*Account, *errors.Error) {
 	acc.secret = ecc
 	return &acc, nil
 }
+
+func sameServer(acc *Account, server string) bool {
+	if acc.Registration == nil {
+		return false
+	}
+
+	registered, err := url.Parse(acc.Registration.URI)
+	if err != nil {
+		return false
+	}
+
+	current, err := url.Parse(server)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(registered.Host, current.Host)
+}
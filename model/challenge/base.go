@@ -1,6 +1,8 @@
 package challenge
 
 import (
+	"strings"
+
 	"github.com/go-acme/lego/v3/challenge"
 
 	"github.com/alphatr/acme-lego/common/config"
@@ -20,8 +22,38 @@ const (
 // Provider 解决方案
 type Provider interface {
 	Type() ProviderType
+	Options() *config.ChallengeOptions
 	Provider(string, *config.DomainConf) (challenge.Provider, *errors.Error)
 }
 
 // ProviderMap ProviderMap
 var ProviderMap = map[string]Provider{}
+
+func init() {
+	config.LookupChallenge = lookupChallenge
+}
+
+// lookupChallenge 返回验证方式支持的 options, 用于检查配置
+func lookupChallenge(name string) (*config.ChallengeOptions, bool) {
+	provider, ok := GetProvider(name)
+	if !ok {
+		return nil, false
+	}
+
+	options := provider.Options()
+	options.DNS = provider.Type() == ProviderDNS
+	return options, true
+}
+
+// GetProvider 根据名称获取 Provider, 未注册的 dns-<name> 使用 lego 自带的同名 DNS Provider
+func GetProvider(name string) (Provider, bool) {
+	if provider, ok := ProviderMap[name]; ok {
+		return provider, true
+	}
+
+	if strings.HasPrefix(name, "dns-") && len(name) > len("dns-") {
+		return &DNSLegoProvider{name: strings.TrimPrefix(name, "dns-")}, true
+	}
+
+	return nil, false
+}
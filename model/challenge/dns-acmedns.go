@@ -0,0 +1,69 @@
+package challenge
+
+import (
+	"os"
+	"path"
+
+	"github.com/cpu/goacmedns"
+	"github.com/go-acme/lego/v3/challenge"
+	"github.com/go-acme/lego/v3/providers/dns/acmedns"
+
+	"github.com/alphatr/acme-lego/common/bootstrap"
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+func init() {
+	ProviderMap["dns-acmedns"] = &DNSAcmeDNSProvider{}
+}
+
+// DNSAcmeDNSProvider 通过 CNAME 委托给 acme-dns 服务器验证
+type DNSAcmeDNSProvider struct{}
+
+// Type 返回注册的类型
+func (ins *DNSAcmeDNSProvider) Type() ProviderType {
+	return ProviderDNS
+}
+
+// Options 支持的 options
+func (ins *DNSAcmeDNSProvider) Options() *config.ChallengeOptions {
+	return &config.ChallengeOptions{Required: []string{"api-base"}}
+}
+
+// Provider Provider 实体, acme-dns 账户保存在证书目录的 acme-dns.json 中
+func (ins *DNSAcmeDNSProvider) Provider(domain string, conf *config.DomainConf) (challenge.Provider, *errors.Error) {
+	apiBase := conf.Options["api-base"]
+	if len(apiBase) == 0 {
+		return nil, errors.NewError(errors.ModelChalDNSConfigErrno, nil, "acmedns")
+	}
+
+	certPath := path.Join(config.Config.RootDir, "certificates", domain)
+	if err := os.MkdirAll(certPath, 0700); err != nil {
+		return nil, errors.NewError(errors.CommonMakeDirErrno, err, certPath)
+	}
+
+	client := goacmedns.NewClient(apiBase)
+	storage := goacmedns.NewFileStorage(path.Join(certPath, "acme-dns.json"), 0600)
+
+	provider, err := acmedns.NewDNSProviderClient(client, storage)
+	if err != nil {
+		return nil, errors.NewError(errors.ModelChalDNSConfigErrno, err, "acmedns")
+	}
+
+	return &DNSAcmeDNSProviderServer{DNSProvider: provider}, nil
+}
+
+// DNSAcmeDNSProviderServer 首次使用时注册 acme-dns 账户并提示需要添加的 CNAME 记录
+type DNSAcmeDNSProviderServer struct {
+	*acmedns.DNSProvider
+}
+
+// Present 更新 acme-dns 的 TXT 记录
+func (s *DNSAcmeDNSProviderServer) Present(domain, token, keyAuth string) error {
+	err := s.DNSProvider.Present(domain, token, keyAuth)
+	if cname, ok := err.(acmedns.ErrCNAMERequired); ok {
+		bootstrap.Log.Warnf("[%s] acme-dns account registered, create the record and run again: %s CNAME %s.", domain, cname.FQDN, cname.Target)
+	}
+
+	return err
+}
@@ -20,10 +20,18 @@ func (ins *DNSCloudflareProvider) Type() ProviderType {
 	return ProviderDNS
 }
 
+// Options 支持的 options
+func (ins *DNSCloudflareProvider) Options() *config.ChallengeOptions {
+	return &config.ChallengeOptions{Required: []string{"token"}}
+}
+
 // Provider Provider 实体
 func (ins *DNSCloudflareProvider) Provider(domain string, conf *config.DomainConf) (challenge.Provider, *errors.Error) {
 	config := cloudflare.NewDefaultConfig()
 	config.AuthToken = conf.Options["token"]
+	if conf.DNS.TTL > 0 {
+		config.TTL = conf.DNS.TTL
+	}
 
 	provider, err := cloudflare.NewDNSProviderConfig(config)
 	if err != nil {
@@ -0,0 +1,71 @@
+package challenge
+
+import (
+	"strconv"
+
+	"github.com/go-acme/lego/v3/challenge"
+	"github.com/go-acme/lego/v3/challenge/dns01"
+
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+func init() {
+	ProviderMap["dns-exec"] = &DNSExecProvider{}
+}
+
+// DNSExecProvider 执行外部命令修改 DNS
+type DNSExecProvider struct{}
+
+// Type 返回注册的类型
+func (ins *DNSExecProvider) Type() ProviderType {
+	return ProviderDNS
+}
+
+// Options 支持的 options
+func (ins *DNSExecProvider) Options() *config.ChallengeOptions {
+	return &config.ChallengeOptions{Required: []string{"command"}, Optional: []string{"timeout"}, Durations: []string{"timeout"}}
+}
+
+// Provider Provider 实体
+func (ins *DNSExecProvider) Provider(domain string, conf *config.DomainConf) (challenge.Provider, *errors.Error) {
+	runner, err := newExecRunner("dns-exec", conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DNSExecProviderServer{runner: runner, ttl: conf.DNS.TTL}, nil
+}
+
+// DNSExecProviderServer 调用命令添加和删除 TXT 记录
+type DNSExecProviderServer struct {
+	runner *execRunner
+	ttl    int
+}
+
+// Present 添加 TXT 记录, 命令参数为 present <domain> <fqdn> <value>
+func (s *DNSExecProviderServer) Present(domain, token, keyAuth string) error {
+	return s.run("present", domain, token, keyAuth)
+}
+
+// CleanUp 删除 TXT 记录, 命令参数为 cleanup <domain> <fqdn> <value>
+func (s *DNSExecProviderServer) CleanUp(domain, token, keyAuth string) error {
+	return s.run("cleanup", domain, token, keyAuth)
+}
+
+func (s *DNSExecProviderServer) run(action, domain, token, keyAuth string) error {
+	fqdn, value := dns01.GetRecord(domain, keyAuth)
+	env := []string{
+		"LEGO_DOMAIN=" + domain,
+		"LEGO_FQDN=" + fqdn,
+		"LEGO_VALUE=" + value,
+		"LEGO_TOKEN=" + token,
+		"LEGO_KEY_AUTH=" + keyAuth,
+	}
+
+	if s.ttl > 0 {
+		env = append(env, "LEGO_TTL="+strconv.Itoa(s.ttl))
+	}
+
+	return s.runner.run(action, domain, env, domain, fqdn, value)
+}
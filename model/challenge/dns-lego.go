@@ -0,0 +1,129 @@
+package challenge
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-acme/lego/v3/challenge"
+	"github.com/go-acme/lego/v3/providers/dns"
+
+	"github.com/alphatr/acme-lego/common"
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+// lego 的 DNS Provider 从环境变量读取配置, 设置环境变量时需要加锁
+var legoEnvMutex sync.Mutex
+
+// legoEnvPrefix 环境变量前缀与名称不一致的 Provider
+var legoEnvPrefix = map[string]string{
+	"acme-dns":     "ACME_DNS",
+	"alidns":       "ALICLOUD",
+	"auroradns":    "AURORA",
+	"digitalocean": "DO",
+	"edgedns":      "AKAMAI",
+	"fastdns":      "AKAMAI",
+	"gcloud":       "GCE",
+	"linodev4":     "LINODE",
+	"liquidweb":    "LIQUID_WEB",
+	"namedotcom":   "NAMECOM",
+	"oraclecloud":  "OCI",
+	"route53":      "AWS",
+}
+
+// legoEnvExtra Provider 文档中不以前缀开头的环境变量
+var legoEnvExtra = map[string][]string{
+	"designate": {"OS_AUTH_URL", "OS_USERNAME", "OS_PASSWORD", "OS_PROJECT_NAME", "OS_TENANT_NAME", "OS_REGION_NAME", "OS_PROJECT_ID"},
+	"gcloud":    {"GOOGLE_APPLICATION_CREDENTIALS"},
+	"lightsail": {"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "DNS_ZONE"},
+	"vegadns":   {"SECRET_VEGADNS_KEY", "SECRET_VEGADNS_SECRET"},
+}
+
+// DNSLegoProvider 通过名称使用 lego 自带的 DNS Provider, 如 dns-route53, dns-alidns
+type DNSLegoProvider struct {
+	name string
+}
+
+// Type 返回注册的类型
+func (ins *DNSLegoProvider) Type() ProviderType {
+	return ProviderDNS
+}
+
+// Options options 作为环境变量传给 lego, 不做检查
+func (ins *DNSLegoProvider) Options() *config.ChallengeOptions {
+	return &config.ChallengeOptions{Any: true}
+}
+
+// Provider Provider 实体, options 的 key 转换为环境变量名, 如 alicloud-access-key 对应 ALICLOUD_ACCESS_KEY
+func (ins *DNSLegoProvider) Provider(domain string, conf *config.DomainConf) (challenge.Provider, *errors.Error) {
+	legoEnvMutex.Lock()
+	defer legoEnvMutex.Unlock()
+
+	prefix := common.DefaultString(legoEnvPrefix[ins.name], strings.ToUpper(ins.name))
+
+	envs := map[string]string{}
+	if conf.DNS.TTL > 0 {
+		envs[prefix+"_TTL"] = strconv.Itoa(conf.DNS.TTL)
+	}
+
+	// options 中直接设置的值优先, 只设置 Provider 使用的环境变量, 其他验证方式的 options 不会覆盖无关的环境变量
+	for key, value := range conf.Options {
+		name := strings.ToUpper(strings.Replace(key, "-", "_", -1))
+		if ins.envAllowed(prefix, name) {
+			envs[name] = value
+		}
+	}
+
+	restore := setEnv(envs)
+	defer restore()
+
+	provider, err := dns.NewDNSChallengeProviderByName(ins.name)
+	if err != nil {
+		return nil, errors.NewError(errors.ModelChalDNSConfigErrno, err, ins.name)
+	}
+
+	return provider, nil
+}
+
+// envAllowed 环境变量以 Provider 的前缀开头, 或者是文档中的其他环境变量
+func (ins *DNSLegoProvider) envAllowed(prefix string, name string) bool {
+	if strings.HasPrefix(name, prefix+"_") {
+		return true
+	}
+
+	for _, item := range legoEnvExtra[ins.name] {
+		if item == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setEnv 设置环境变量, 返回恢复原环境变量的函数
+func setEnv(envs map[string]string) func() {
+	origin := map[string]*string{}
+
+	for name, value := range envs {
+		if old, ok := os.LookupEnv(name); ok {
+			origin[name] = &old
+		} else {
+			origin[name] = nil
+		}
+
+		os.Setenv(name, value)
+	}
+
+	return func() {
+		for name, value := range origin {
+			if value == nil {
+				os.Unsetenv(name)
+				continue
+			}
+
+			os.Setenv(name, *value)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package challenge
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-acme/lego/v3/challenge"
+	"github.com/go-acme/lego/v3/challenge/dns01"
+
+	"github.com/alphatr/acme-lego/common"
+	"github.com/alphatr/acme-lego/common/bootstrap"
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+const (
+	defaultManualTTL          = 120
+	defaultManualPollTimeout  = 10 * time.Minute
+	defaultManualPollInterval = 10 * time.Second
+)
+
+// 默认用于检查 TXT 记录的公共 DNS
+var defaultManualResolvers = []string{"8.8.8.8:53", "1.1.1.1:53"}
+
+func init() {
+	ProviderMap["dns-manual"] = &DNSManualProvider{}
+}
+
+// DNSManualProvider 手动添加 TXT 记录
+type DNSManualProvider struct{}
+
+// Type 返回注册的类型
+func (ins *DNSManualProvider) Type() ProviderType {
+	return ProviderDNS
+}
+
+// Options 支持的 options
+func (ins *DNSManualProvider) Options() *config.ChallengeOptions {
+	return &config.ChallengeOptions{Optional: []string{"poll", "poll-resolvers", "poll-timeout"}, Durations: []string{"poll-timeout"}}
+}
+
+// Provider Provider 实体
+func (ins *DNSManualProvider) Provider(domain string, conf *config.DomainConf) (challenge.Provider, *errors.Error) {
+	provider := &DNSManualProviderServer{
+		poll:      conf.Options["poll"] == "true",
+		resolvers: defaultManualResolvers,
+		timeout:   defaultManualPollTimeout,
+		ttl:       defaultManualTTL,
+	}
+
+	if conf.DNS.TTL > 0 {
+		provider.ttl = conf.DNS.TTL
+	}
+
+	if resolvers := conf.Options["poll-resolvers"]; len(resolvers) > 0 {
+		provider.resolvers = []string{}
+		for _, item := range strings.Split(resolvers, ",") {
+			provider.resolvers = append(provider.resolvers, strings.TrimSpace(item))
+		}
+	}
+
+	if timeout := conf.Options["poll-timeout"]; len(timeout) > 0 {
+		duration, err := config.ParseDuration(timeout, false)
+		if err != nil {
+			return nil, errors.NewError(errors.ConfigParseDurationErrno, err, "poll-timeout")
+		}
+
+		provider.timeout = duration
+	}
+
+	return provider, nil
+}
+
+// DNSManualProviderServer 输出 TXT 记录并等待终端确认
+type DNSManualProviderServer struct {
+	poll      bool
+	resolvers []string
+	timeout   time.Duration
+	ttl       int
+}
+
+// Present 输出需要添加的 TXT 记录, 非终端环境下直接返回错误
+func (s *DNSManualProviderServer) Present(domain, token, keyAuth string) error {
+	fqdn, value := dns01.GetRecord(domain, keyAuth)
+
+	if !common.StdinIsTerminal() {
+		bootstrap.Log.Warnf("[%s] dns-manual requires manual action: %s TXT %q", domain, fqdn, value)
+		return errors.NewError(errors.ModelChalManualErrno, nil, domain)
+	}
+
+	fmt.Printf("Please create the TXT record and press Enter to continue:\n\n%s %d IN TXT %q\n\n", fqdn, s.ttl, value)
+	if _, err := bufio.NewReader(os.Stdin).ReadString('\n'); err != nil {
+		return errors.NewError(errors.ModelChalManualErrno, err, domain)
+	}
+
+	if !s.poll {
+		return nil
+	}
+
+	return s.waitRecord(domain, fqdn, value)
+}
+
+// CleanUp 提示可以删除 TXT 记录
+func (s *DNSManualProviderServer) CleanUp(domain, token, keyAuth string) error {
+	fqdn, _ := dns01.GetRecord(domain, keyAuth)
+	bootstrap.Log.Infof("[%s] dns-manual: the TXT record %s can be removed", domain, fqdn)
+	return nil
+}
+
+// waitRecord 轮询公共 DNS 直到所有服务器都能查询到 TXT 记录
+func (s *DNSManualProviderServer) waitRecord(domain, fqdn, value string) error {
+	deadline := time.Now().Add(s.timeout)
+
+	for {
+		pending := []string{}
+		for _, server := range s.resolvers {
+			if !lookupTXT(server, fqdn, value) {
+				pending = append(pending, server)
+			}
+		}
+
+		if len(pending) == 0 {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return errors.NewError(errors.ModelChalManualPollErrno, nil, fqdn)
+		}
+
+		bootstrap.Log.Infof("[%s] dns-manual: waiting for %s on %s", domain, fqdn, strings.Join(pending, ", "))
+		time.Sleep(defaultManualPollInterval)
+	}
+}
+
+// lookupTXT 通过指定的 DNS 服务器查询 TXT 记录是否存在
+func lookupTXT(server, fqdn, value string) bool {
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			dialer := net.Dialer{}
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	records, err := resolver.LookupTXT(ctx, fqdn)
+	if err != nil {
+		return false
+	}
+
+	for _, record := range records {
+		if record == value {
+			return true
+		}
+	}
+
+	return false
+}
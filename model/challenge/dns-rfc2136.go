@@ -0,0 +1,64 @@
+package challenge
+
+import (
+	"strings"
+
+	"github.com/go-acme/lego/v3/challenge"
+	"github.com/go-acme/lego/v3/providers/dns/rfc2136"
+
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+func init() {
+	ProviderMap["dns-rfc2136"] = &DNSRFC2136Provider{}
+}
+
+// DNSRFC2136Provider RFC 2136 动态更新 DNS
+type DNSRFC2136Provider struct{}
+
+// Type 返回注册的类型
+func (ins *DNSRFC2136Provider) Type() ProviderType {
+	return ProviderDNS
+}
+
+// Options 支持的 options
+func (ins *DNSRFC2136Provider) Options() *config.ChallengeOptions {
+	return &config.ChallengeOptions{Required: []string{"nameserver"}, Optional: []string{"tsig-key", "tsig-secret", "tsig-algorithm"}}
+}
+
+// Provider Provider 实体
+func (ins *DNSRFC2136Provider) Provider(domain string, conf *config.DomainConf) (challenge.Provider, *errors.Error) {
+	config := rfc2136.NewDefaultConfig()
+	config.Nameserver = conf.Options["nameserver"]
+	config.TSIGKey = conf.Options["tsig-key"]
+	config.TSIGSecret = conf.Options["tsig-secret"]
+	if conf.DNS.TTL > 0 {
+		config.TTL = conf.DNS.TTL
+	}
+
+	if algorithm := conf.Options["tsig-algorithm"]; len(algorithm) > 0 {
+		config.TSIGAlgorithm = tsigAlgorithm(algorithm)
+	}
+
+	provider, err := rfc2136.NewDNSProviderConfig(config)
+	if err != nil {
+		return nil, errors.NewError(errors.ModelChalDNSConfigErrno, err, "rfc2136")
+	}
+
+	return provider, nil
+}
+
+// tsigAlgorithm 支持 hmac-sha256 这样的简写, 转换为 miekg/dns 使用的算法名
+func tsigAlgorithm(input string) string {
+	algorithm := strings.ToLower(input)
+	if algorithm == "hmac-md5" {
+		return "hmac-md5.sig-alg.reg.int."
+	}
+
+	if !strings.HasSuffix(algorithm, ".") {
+		algorithm += "."
+	}
+
+	return algorithm
+}
@@ -0,0 +1,57 @@
+package challenge
+
+import (
+	"strings"
+	"time"
+
+	"github.com/alphatr/acme-lego/common"
+	"github.com/alphatr/acme-lego/common/bootstrap"
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+const defaultExecTimeout = 2 * time.Minute
+
+// execRunner 执行用户配置的验证命令
+type execRunner struct {
+	name    string
+	command string
+	timeout time.Duration
+}
+
+// newExecRunner 从 options.command 和 options.timeout 创建
+func newExecRunner(name string, conf *config.DomainConf) (*execRunner, *errors.Error) {
+	runner := &execRunner{name: name, command: conf.Options["command"], timeout: defaultExecTimeout}
+	if len(runner.command) == 0 {
+		return nil, errors.NewError(errors.ModelChalExecConfigErrno, nil, name)
+	}
+
+	if timeout := conf.Options["timeout"]; len(timeout) > 0 {
+		duration, err := config.ParseDuration(timeout, false)
+		if err != nil {
+			return nil, errors.NewError(errors.ModelChalExecConfigErrno, err, name)
+		}
+
+		runner.timeout = duration
+	}
+
+	return runner, nil
+}
+
+// run 执行命令, 参数为 action 加 args, 环境变量为 LEGO_ACTION 加 env
+func (ins *execRunner) run(action, domain string, env []string, args ...string) error {
+	env = append(env, "LEGO_ACTION="+action)
+	output, err := common.RunCommandEnv(ins.command, env, ins.timeout, append([]string{action}, args...)...)
+
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if len(line) > 0 {
+			bootstrap.Log.Infof("[%s] %s %s: %s", domain, ins.name, action, line)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
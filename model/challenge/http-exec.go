@@ -0,0 +1,62 @@
+package challenge
+
+import (
+	"github.com/go-acme/lego/v3/challenge"
+	"github.com/go-acme/lego/v3/challenge/http01"
+
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+func init() {
+	ProviderMap["http-exec"] = &HTTPExecProvider{}
+}
+
+// HTTPExecProvider 执行外部命令发布 HTTP 验证文件
+type HTTPExecProvider struct{}
+
+// Type 返回注册的类型
+func (ins *HTTPExecProvider) Type() ProviderType {
+	return ProviderHTTP
+}
+
+// Options 支持的 options
+func (ins *HTTPExecProvider) Options() *config.ChallengeOptions {
+	return &config.ChallengeOptions{Required: []string{"command"}, Optional: []string{"timeout"}, Durations: []string{"timeout"}}
+}
+
+// Provider Provider 实体
+func (ins *HTTPExecProvider) Provider(domain string, conf *config.DomainConf) (challenge.Provider, *errors.Error) {
+	runner, err := newExecRunner("http-exec", conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HTTPExecProviderServer{runner: runner}, nil
+}
+
+// HTTPExecProviderServer 调用命令发布和删除验证内容
+type HTTPExecProviderServer struct {
+	runner *execRunner
+}
+
+// Present 发布验证内容, 命令参数为 present <domain> <token> <key-auth>
+func (s *HTTPExecProviderServer) Present(domain, token, keyAuth string) error {
+	return s.run("present", domain, token, keyAuth)
+}
+
+// CleanUp 删除验证内容, 命令参数为 cleanup <domain> <token> <key-auth>
+func (s *HTTPExecProviderServer) CleanUp(domain, token, keyAuth string) error {
+	return s.run("cleanup", domain, token, keyAuth)
+}
+
+func (s *HTTPExecProviderServer) run(action, domain, token, keyAuth string) error {
+	env := []string{
+		"LEGO_DOMAIN=" + domain,
+		"LEGO_PATH=" + http01.ChallengePath(token),
+		"LEGO_TOKEN=" + token,
+		"LEGO_KEY_AUTH=" + keyAuth,
+	}
+
+	return s.runner.run(action, domain, env, domain, token, keyAuth)
+}
@@ -27,6 +27,11 @@ func (ins *HTTPPathProvider) Type() ProviderType {
 	return ProviderHTTP
 }
 
+// Options 支持的 options
+func (ins *HTTPPathProvider) Options() *config.ChallengeOptions {
+	return &config.ChallengeOptions{Required: []string{"public"}}
+}
+
 // Provider Provider 实体
 func (ins *HTTPPathProvider) Provider(domain string, conf *config.DomainConf) (challenge.Provider, *errors.Error) {
 	provider, err := webroot.NewHTTPProvider(conf.Options["public"])
@@ -4,43 +4,51 @@ import (
 	"net"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/go-acme/lego/v3/challenge"
+	"github.com/go-acme/lego/v3/challenge/http01"
 
+	"github.com/alphatr/acme-lego/common"
 	"github.com/alphatr/acme-lego/common/config"
 	"github.com/alphatr/acme-lego/common/errors"
 )
 
+// 每个监听地址只有一个验证服务器, 多个域名和授权共用
+var (
+	httpServers      = map[string]*HTTPProviderServer{}
+	httpServersMutex sync.Mutex
+)
+
 // HTTPProviderServer HTTP 端口转发服务器
 type HTTPProviderServer struct {
-	iface    string
-	port     string
+	address  string
+	tokens   map[string]string
+	mutex    sync.Mutex
 	done     chan bool
 	listener net.Listener
 }
 
 func init() {
-	ProviderMap["http-port"] = &HTTPPortProvider{isHTTPS: false}
-	ProviderMap["https-port"] = &HTTPPortProvider{isHTTPS: true}
+	ProviderMap["http-port"] = &HTTPPortProvider{}
 }
 
 // HTTPPortProvider HTTPPortProvider
-type HTTPPortProvider struct {
-	isHTTPS bool
-}
+type HTTPPortProvider struct{}
 
 // Type 返回注册的类型
 func (ins *HTTPPortProvider) Type() ProviderType {
-	if ins.isHTTPS {
-		return ProviderTLS
-	}
-
 	return ProviderHTTP
 }
 
+// Options 支持的 options
+func (ins *HTTPPortProvider) Options() *config.ChallengeOptions {
+	return &config.ChallengeOptions{Required: []string{"server"}, Servers: []string{"server"}}
+}
+
 // Provider Provider 实体
 func (ins *HTTPPortProvider) Provider(domain string, conf *config.DomainConf) (challenge.Provider, *errors.Error) {
-	host, port, err := net.SplitHostPort(conf.Options["server"])
+	host, port, err := config.ParseServer(conf.Options["server"])
 	if err != nil {
 		return nil, errors.NewError(errors.CommonParseHostPortErrno, err)
 	}
@@ -49,53 +57,98 @@ func (ins *HTTPPortProvider) Provider(domain string, conf *config.DomainConf) (c
 	return provider, nil
 }
 
-// NewHTTPProviderServer 创建端口转发服务器
+// NewHTTPProviderServer 返回监听地址对应的端口转发服务器
 func NewHTTPProviderServer(iface, port string) *HTTPProviderServer {
-	return &HTTPProviderServer{iface: iface, port: port}
+	address := net.JoinHostPort(iface, common.DefaultString(port, "80"))
+
+	httpServersMutex.Lock()
+	defer httpServersMutex.Unlock()
+
+	if server, ok := httpServers[address]; ok {
+		return server
+	}
+
+	server := &HTTPProviderServer{address: address, tokens: map[string]string{}}
+	httpServers[address] = server
+	return server
 }
 
-// Present 启动服务器
+// Present 添加验证内容, 服务器未启动时启动
 func (s *HTTPProviderServer) Present(domain, token, keyAuth string) error {
-	if s.port == "" {
-		s.port = "80"
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := httpTokenKey(domain, token)
+	s.tokens[key] = keyAuth
+	if s.listener != nil {
+		return nil
 	}
 
-	var err error
-	s.listener, err = net.Listen("tcp", net.JoinHostPort(s.iface, s.port))
+	listener, err := net.Listen("tcp", s.address)
 	if err != nil {
+		delete(s.tokens, key)
 		return errors.NewError(errors.ModelChalServerStartErrno, err)
 	}
 
+	s.listener = listener
 	s.done = make(chan bool)
-	go s.serve(domain, token, keyAuth)
+	go s.serve(listener)
 	return nil
 }
 
-// CleanUp 关闭服务器
+// CleanUp 移除验证内容, 没有待验证的内容时关闭服务器
 func (s *HTTPProviderServer) CleanUp(domain, token, keyAuth string) error {
-	if s.listener == nil {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.tokens, httpTokenKey(domain, token))
+	if s.listener == nil || len(s.tokens) > 0 {
 		return nil
 	}
 
 	s.listener.Close()
 	<-s.done
+	s.listener = nil
 	return nil
 }
 
-func (s *HTTPProviderServer) serve(domain, token, keyAuth string) {
-	mux := http.NewServeMux()
-	mux.HandleFunc("/.well-known/acme-challenge/"+token, func(w http.ResponseWriter, r *http.Request) {
-		if strings.HasPrefix(r.Host, domain) && r.Method == "GET" {
-			w.Header().Add("Content-Type", "text/plain")
-			w.Write([]byte(keyAuth))
-		}
-	})
-
+func (s *HTTPProviderServer) serve(listener net.Listener) {
 	httpServer := &http.Server{
-		Handler: mux,
+		Handler: http.HandlerFunc(s.handle),
 	}
 
 	httpServer.SetKeepAlivesEnabled(false)
-	httpServer.Serve(s.listener)
+	httpServer.Serve(listener)
 	s.done <- true
 }
+
+func (s *HTTPProviderServer) handle(w http.ResponseWriter, r *http.Request) {
+	prefix := http01.ChallengePath("")
+	if r.Method != http.MethodGet || !strings.HasPrefix(r.URL.Path, prefix) {
+		http.NotFound(w, r)
+		return
+	}
+
+	// 请求的 Host 可能带有端口
+	host := r.Host
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+
+	s.mutex.Lock()
+	keyAuth, ok := s.tokens[httpTokenKey(host, strings.TrimPrefix(r.URL.Path, prefix))]
+	s.mutex.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Add("Content-Type", "text/plain")
+	w.Write([]byte(keyAuth))
+}
+
+// httpTokenKey 域名和 token 一起作为 key, 域名不区分大小写
+func httpTokenKey(domain, token string) string {
+	return strings.ToLower(strings.TrimSuffix(domain, ".")) + "/" + token
+}
@@ -0,0 +1,176 @@
+package challenge
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-acme/lego/v3/challenge"
+	"github.com/go-acme/lego/v3/challenge/http01"
+
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+const selfCheckTimeout = 10 * time.Second
+
+// CheckResult 单个域名的自检结果
+type CheckResult struct {
+	Domain    string
+	Challenge string
+	Addresses []string
+	Error     *errors.Error
+}
+
+// SelfCheck 通过配置的 HTTP 验证方式放置探测文件, 再自行请求和解析域名, 检查 CA 是否可以完成验证
+func SelfCheck(domain string, conf *config.DomainConf) []*CheckResult {
+	results := []*CheckResult{}
+	providers := map[string]challenge.Provider{}
+
+	for _, identifier := range conf.Domains {
+		name := conf.ChallengeFor(identifier)
+		item, ok := GetProvider(name)
+		if !ok || item.Type() != ProviderHTTP || strings.HasPrefix(identifier, "*.") {
+			continue
+		}
+
+		result := &CheckResult{Domain: identifier, Challenge: name}
+		results = append(results, result)
+
+		provider, ok := providers[name]
+		if !ok {
+			var err *errors.Error
+			if provider, err = item.Provider(domain, conf); err != nil {
+				result.Error = errors.NewError(errors.ModelChalSelfCheckErrno, err, identifier)
+				continue
+			}
+
+			providers[name] = provider
+		}
+
+		if err := checkHTTP(result, provider); err != nil {
+			result.Error = errors.NewError(errors.ModelChalSelfCheckErrno, err, identifier)
+		}
+	}
+
+	return results
+}
+
+// checkHTTP 检查单个域名, 返回的错误说明失败的原因
+func checkHTTP(result *CheckResult, provider challenge.Provider) error {
+	ctx, cancel := context.WithTimeout(context.Background(), selfCheckTimeout)
+	defer cancel()
+
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, result.Domain)
+	if err != nil || len(addresses) == 0 {
+		return fmt.Errorf("no A/AAAA record found: %v", err)
+	}
+
+	for _, address := range addresses {
+		result.Addresses = append(result.Addresses, address.IP.String())
+	}
+
+	token, err := probeToken()
+	if err != nil {
+		return err
+	}
+
+	keyAuth := token + ".lego-self-check"
+	if err := provider.Present(result.Domain, token, keyAuth); err != nil {
+		return fmt.Errorf("failed to place the probe token: %v", err)
+	}
+
+	defer provider.CleanUp(result.Domain, token, keyAuth)
+
+	status, content, err := fetchProbe(result.Domain, token)
+	switch {
+	case err != nil:
+		return fmt.Errorf("request failed: %v%s", err, addressHint(addresses))
+	case status == http.StatusNotFound:
+		return fmt.Errorf("probe token not found: %s%s", notFoundReason(result.Challenge), addressHint(addresses))
+	case status != http.StatusOK:
+		return fmt.Errorf("unexpected status code %d%s", status, addressHint(addresses))
+	case content != keyAuth:
+		return fmt.Errorf("unexpected content, the request is answered by another server%s", addressHint(addresses))
+	}
+
+	return nil
+}
+
+// fetchProbe 与 CA 一样请求探测地址, 跟随跳转且不校验 HTTPS 证书
+func fetchProbe(domain, token string) (int, string, error) {
+	client := &http.Client{
+		Timeout: selfCheckTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+
+	response, err := client.Get("http://" + domain + http01.ChallengePath(token))
+	if err != nil {
+		return 0, "", err
+	}
+
+	defer response.Body.Close()
+
+	content, err := ioutil.ReadAll(io.LimitReader(response.Body, 4096))
+	if err != nil {
+		return 0, "", err
+	}
+
+	return response.StatusCode, strings.TrimSpace(string(content)), nil
+}
+
+func probeToken() (string, error) {
+	buffer := make([]byte, 24)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buffer), nil
+}
+
+// notFoundReason 根据验证方式给出 404 的可能原因
+func notFoundReason(name string) string {
+	switch name {
+	case "http-path":
+		return "the web server doesn't serve options.public as /.well-known/acme-challenge/"
+	case "http-port":
+		return "the proxy doesn't forward /.well-known/acme-challenge/ to options.server"
+	default:
+		return "the challenge content is not served"
+	}
+}
+
+// addressHint 域名没有解析到本机地址时提示
+func addressHint(addresses []net.IPAddr) string {
+	local := map[string]bool{}
+	if list, err := net.InterfaceAddrs(); err == nil {
+		for _, item := range list {
+			if ipNet, ok := item.(*net.IPNet); ok {
+				local[ipNet.IP.String()] = true
+			}
+		}
+	}
+
+	remote := []string{}
+	for _, address := range addresses {
+		if !local[address.IP.String()] {
+			remote = append(remote, address.IP.String())
+		}
+	}
+
+	if len(remote) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" (dns points to %s which is not an address of this host)", strings.Join(remote, ", "))
+}
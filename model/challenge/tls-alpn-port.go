@@ -0,0 +1,222 @@
+package challenge
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-acme/lego/v3/challenge"
+	"github.com/go-acme/lego/v3/challenge/tlsalpn01"
+
+	"github.com/alphatr/acme-lego/common/bootstrap"
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+)
+
+const tlsALPNConnTimeout = 10 * time.Second
+
+// PROXY protocol v2 的固定签名
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+func init() {
+	ProviderMap["https-port"] = &TLSALPNPortProvider{}
+}
+
+// TLSALPNPortProvider TLS-ALPN-01 端口验证
+type TLSALPNPortProvider struct{}
+
+// Type 返回注册的类型
+func (ins *TLSALPNPortProvider) Type() ProviderType {
+	return ProviderTLS
+}
+
+// Options 支持的 options
+func (ins *TLSALPNPortProvider) Options() *config.ChallengeOptions {
+	return &config.ChallengeOptions{Required: []string{"server"}, Optional: []string{"proxy-protocol"}, Servers: []string{"server"}}
+}
+
+// Provider Provider 实体
+func (ins *TLSALPNPortProvider) Provider(domain string, conf *config.DomainConf) (challenge.Provider, *errors.Error) {
+	host, port, err := config.ParseServer(conf.Options["server"])
+	if err != nil {
+		return nil, errors.NewError(errors.CommonParseHostPortErrno, err)
+	}
+
+	provider := NewTLSALPNProviderServer(host, port)
+	provider.proxyProtocol = conf.Options["proxy-protocol"] == "true"
+	return provider, nil
+}
+
+// TLSALPNProviderServer TLS-ALPN-01 验证服务器
+type TLSALPNProviderServer struct {
+	iface         string
+	port          string
+	proxyProtocol bool
+	certs         map[string]*tls.Certificate
+	mutex         sync.Mutex
+	done          chan bool
+	listener      net.Listener
+}
+
+// NewTLSALPNProviderServer 创建 TLS-ALPN-01 验证服务器
+func NewTLSALPNProviderServer(iface, port string) *TLSALPNProviderServer {
+	return &TLSALPNProviderServer{iface: iface, port: port, certs: map[string]*tls.Certificate{}}
+}
+
+// Present 生成验证证书并启动服务器
+func (s *TLSALPNProviderServer) Present(domain, token, keyAuth string) error {
+	if s.port == "" {
+		s.port = "443"
+	}
+
+	cert, err := tlsalpn01.ChallengeCert(domain, keyAuth)
+	if err != nil {
+		return errors.NewError(errors.ModelChalTLSCertErrno, err, domain)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.certs[strings.ToLower(domain)] = cert
+	if s.listener != nil {
+		return nil
+	}
+
+	s.listener, err = net.Listen("tcp", net.JoinHostPort(s.iface, s.port))
+	if err != nil {
+		delete(s.certs, strings.ToLower(domain))
+		return errors.NewError(errors.ModelChalServerStartErrno, err)
+	}
+
+	s.done = make(chan bool)
+	go s.serve(s.listener)
+	return nil
+}
+
+// CleanUp 移除验证证书, 没有待验证域名时关闭服务器
+func (s *TLSALPNProviderServer) CleanUp(domain, token, keyAuth string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.certs, strings.ToLower(domain))
+	if s.listener == nil || len(s.certs) > 0 {
+		return nil
+	}
+
+	s.listener.Close()
+	<-s.done
+	s.listener = nil
+	return nil
+}
+
+func (s *TLSALPNProviderServer) serve(listener net.Listener) {
+	tlsConf := &tls.Config{
+		NextProtos:     []string{tlsalpn01.ACMETLS1Protocol},
+		GetCertificate: s.getCertificate,
+	}
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			break
+		}
+
+		go s.handle(conn, tlsConf)
+	}
+
+	s.done <- true
+}
+
+func (s *TLSALPNProviderServer) handle(conn net.Conn, tlsConf *tls.Config) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(tlsALPNConnTimeout))
+
+	if s.proxyProtocol {
+		var err error
+		if conn, err = readProxyHeader(conn); err != nil {
+			bootstrap.Log.Debugf("tls-alpn-proxy-protocol: %s", err)
+			return
+		}
+	}
+
+	if err := tls.Server(conn, tlsConf).Handshake(); err != nil {
+		bootstrap.Log.Debugf("tls-alpn-handshake: %s", err)
+	}
+}
+
+func (s *TLSALPNProviderServer) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	supported := false
+	for _, proto := range hello.SupportedProtos {
+		if proto == tlsalpn01.ACMETLS1Protocol {
+			supported = true
+			break
+		}
+	}
+
+	if !supported {
+		return nil, fmt.Errorf("unsupported protocols %v", hello.SupportedProtos)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	cert, ok := s.certs[strings.ToLower(hello.ServerName)]
+	if !ok {
+		return nil, fmt.Errorf("unknown server name %q", hello.ServerName)
+	}
+
+	return cert, nil
+}
+
+// proxyConn 读取 PROXY 协议头之后的连接, 保留 bufio 中已缓冲的数据
+type proxyConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (conn *proxyConn) Read(input []byte) (int, error) {
+	return conn.reader.Read(input)
+}
+
+// readProxyHeader 跳过 SNI 代理 (nginx stream, HAProxy) 发送的 PROXY 协议 v1/v2 头
+func readProxyHeader(conn net.Conn) (net.Conn, error) {
+	reader := bufio.NewReader(conn)
+
+	signature, err := reader.Peek(len(proxyV2Signature))
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.Equal(signature, proxyV2Signature) {
+		header := make([]byte, len(proxyV2Signature)+4)
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return nil, err
+		}
+
+		length := binary.BigEndian.Uint16(header[len(header)-2:])
+		if _, err := reader.Discard(int(length)); err != nil {
+			return nil, err
+		}
+
+		return &proxyConn{Conn: conn, reader: reader}, nil
+	}
+
+	if !bytes.HasPrefix(signature, []byte("PROXY ")) {
+		return nil, fmt.Errorf("missing proxy protocol header")
+	}
+
+	// v1 头部最长 107 字节, 以 CRLF 结尾
+	line, err := reader.ReadSlice('\n')
+	if err != nil || len(line) > 107 || !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, fmt.Errorf("invalid proxy protocol header")
+	}
+
+	return &proxyConn{Conn: conn, reader: reader}, nil
+}
@@ -2,25 +2,58 @@ package client
 
 import (
 	"crypto"
+	"encoding/base64"
 
+	"github.com/go-acme/lego/v3/acme"
+	"github.com/go-acme/lego/v3/certcrypto"
 	"github.com/go-acme/lego/v3/certificate"
+	"golang.org/x/crypto/ocsp"
 
 	"github.com/alphatr/acme-lego/common/errors"
 )
 
 // CertificateObtain 证书获取
-func (cli *Client) CertificateObtain(domains []string, secret crypto.PrivateKey) (*certificate.Resource, *errors.Error) {
+func (cli *Client) CertificateObtain(domains []string, secret crypto.PrivateKey, mustStaple bool) (*certificate.Resource, *errors.Error) {
 	request := certificate.ObtainRequest{
 		Domains:    domains,
 		PrivateKey: secret,
 		Bundle:     true,
-		MustStaple: true,
+		MustStaple: mustStaple,
 	}
 
-	cert, errs := cli.lego.Certificate.Obtain(request)
+	cert, errs := cli.certifier.Obtain(request)
 	if errs != nil {
 		return nil, errors.NewError(errors.ModelClientObtainErrno, errs)
 	}
 
 	return cert, nil
 }
+
+// CertificateOCSP 获取证书的 OCSP 响应, bundle 为包含签发者证书的证书链
+func (cli *Client) CertificateOCSP(bundle []byte) ([]byte, *ocsp.Response, *errors.Error) {
+	content, response, errs := cli.certifier.GetOCSP(bundle)
+	if errs != nil {
+		return nil, nil, errors.NewError(errors.ModelClientOCSPErrno, errs)
+	}
+
+	return content, response, nil
+}
+
+// CertificateRevoke 证书吊销
+func (cli *Client) CertificateRevoke(content []byte, reason *uint) *errors.Error {
+	certificates, errs := certcrypto.ParsePEMBundle(content)
+	if errs != nil {
+		return errors.NewError(errors.ModelClientRevokeErrno, errs)
+	}
+
+	message := acme.RevokeCertMessage{
+		Certificate: base64.RawURLEncoding.EncodeToString(certificates[0].Raw),
+		Reason:      reason,
+	}
+
+	if errs := cli.core.Certificates.Revoke(message); errs != nil {
+		return errors.NewError(errors.ModelClientRevokeErrno, errs)
+	}
+
+	return nil
+}
@@ -1,49 +1,39 @@
 package client
 
 import (
-	"github.com/go-acme/lego/v3/challenge"
-
 	"github.com/alphatr/acme-lego/common/config"
 	"github.com/alphatr/acme-lego/common/errors"
 	chall "github.com/alphatr/acme-lego/model/challenge"
 )
 
-// SetupChallenge 初始化 Challenge
-func (cli *Client) SetupChallenge(name string, domain string, conf *config.DomainConf) *errors.Error {
-	item, ok := chall.ProviderMap[name]
-	if !ok {
-		return errors.NewError(errors.ModelClientUnknowProviderErrno, nil, name)
-	}
+// SetupChallenge 初始化 Challenge, 同一证书的不同域名可以使用不同的验证方式
+func (cli *Client) SetupChallenge(domain string, conf *config.DomainConf) *errors.Error {
+	entries := map[string]*solverEntry{}
+	solvers := map[string]*solverEntry{}
 
-	provider, err := item.Provider(domain, conf)
-	if err != nil {
-		return errors.NewError(errors.ModelClientProviderErrno, err)
-	}
+	for _, identifier := range conf.Domains {
+		name := conf.ChallengeFor(identifier)
 
-	if err := cli.setProvider(item.Type(), provider); err != nil {
-		return errors.NewError(errors.ModelClientSetProviderErrno, err)
-	}
+		entry, ok := entries[name]
+		if !ok {
+			item, ok := chall.GetProvider(name)
+			if !ok {
+				return errors.NewError(errors.ModelClientUnknowProviderErrno, nil, name)
+			}
 
-	return nil
-}
-
-func (cli *Client) setProvider(input chall.ProviderType, provider challenge.Provider) *errors.Error {
-	switch input {
-	case chall.ProviderHTTP:
-		if err := cli.lego.Challenge.SetHTTP01Provider(provider); err != nil {
-			return errors.NewError(errors.ModelClientTypeProviderErrno, err, input)
-		}
+			provider, err := item.Provider(domain, conf)
+			if err != nil {
+				return errors.NewError(errors.ModelClientProviderErrno, err)
+			}
 
-	case chall.ProviderTLS:
-		if err := cli.lego.Challenge.SetTLSALPN01Provider(provider); err != nil {
-			return errors.NewError(errors.ModelClientTypeProviderErrno, err, input)
+			entry = &solverEntry{Type: item.Type(), Provider: provider}
+			entries[name] = entry
 		}
 
-	case chall.ProviderDNS:
-		if err := cli.lego.Challenge.SetDNS01Provider(provider); err != nil {
-			return errors.NewError(errors.ModelClientTypeProviderErrno, err, input)
-		}
+		solvers[identifier] = entry
 	}
 
+	cli.resolver.solvers = solvers
+	cli.resolver.dns = conf.DNS
 	return nil
 }
@@ -2,14 +2,19 @@ package client
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"time"
 
+	"github.com/go-acme/lego/v3/acme/api"
 	"github.com/go-acme/lego/v3/certcrypto"
+	"github.com/go-acme/lego/v3/certificate"
 	"github.com/go-acme/lego/v3/lego"
 	"github.com/go-acme/lego/v3/log"
 
+	"github.com/alphatr/acme-lego/common"
 	"github.com/alphatr/acme-lego/common/config"
 	"github.com/alphatr/acme-lego/common/errors"
 	"github.com/alphatr/acme-lego/model/account"
@@ -17,9 +22,12 @@ import (
 
 // Client 客户端
 type Client struct {
-	lego    *lego.Client
-	config  *lego.Config
-	account *account.Account
+	lego      *lego.Client
+	core      *api.Core
+	resolver  *resolver
+	certifier *certificate.Certifier
+	config    *lego.Config
+	account   *account.Account
 }
 
 // NewClient 创建新客户端
@@ -27,7 +35,7 @@ func NewClient(acc *account.Account) (*Client, *errors.Error) {
 	log.Logger = &clientLogger{}
 
 	conf := lego.NewConfig(acc)
-	conf.CADirURL = config.Config.AcmeURL
+	conf.CADirURL = common.DefaultString(acc.Server, config.Config.AcmeURL)
 	conf.UserAgent = config.Config.UserAgent
 	conf.Certificate = lego.CertificateConfig{
 		KeyType: certcrypto.RSA2048,
@@ -35,8 +43,13 @@ func NewClient(acc *account.Account) (*Client, *errors.Error) {
 	}
 
 	conf.HTTPClient.Timeout = config.Config.HTTPTimeout * time.Second
-	if config.Config.Dev {
-		conf.HTTPClient.Transport = createInsecureTransport()
+	if config.Config.InsecureSkipVerify || len(config.Config.CABundle) > 0 {
+		transport, err := createTransport()
+		if err != nil {
+			return nil, errors.NewError(errors.ModelClientInitErrno, err)
+		}
+
+		conf.HTTPClient.Transport = transport
 	}
 
 	client, err := lego.NewClient(conf)
@@ -44,10 +57,49 @@ func NewClient(acc *account.Account) (*Client, *errors.Error) {
 		return nil, errors.NewError(errors.ModelClientInitErrno, err)
 	}
 
-	return &Client{lego: client}, nil
+	// lego.Client 未暴露 core, 吊销原因和按域名选择验证方式需要直接使用
+	kid := ""
+	if acc.Registration != nil {
+		kid = acc.Registration.URI
+	}
+
+	core, err := api.New(conf.HTTPClient, conf.UserAgent, conf.CADirURL, kid, acc.GetPrivateKey())
+	if err != nil {
+		return nil, errors.NewError(errors.ModelClientInitErrno, err)
+	}
+
+	res := newResolver(core)
+	certifier := certificate.NewCertifier(core, res, certificate.CertifierOptions{
+		KeyType: conf.Certificate.KeyType,
+		Timeout: conf.Certificate.Timeout,
+	})
+
+	return &Client{lego: client, core: core, resolver: res, certifier: certifier, config: conf, account: acc}, nil
 }
 
-func createInsecureTransport() *http.Transport {
+func createTransport() (*http.Transport, *errors.Error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.Config.InsecureSkipVerify}
+
+	// 私有 CA 的根证书追加到系统证书池, 不影响公共 CA 的校验
+	if len(config.Config.CABundle) > 0 {
+		content, err := ioutil.ReadFile(config.Config.CABundle)
+		if err != nil {
+			err := errors.NewError(errors.CommonFileReadErrno, err, config.Config.CABundle)
+			return nil, errors.NewError(errors.ModelClientCABundleErrno, err, config.Config.CABundle)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(content) {
+			return nil, errors.NewError(errors.ModelClientCABundleErrno, nil, config.Config.CABundle)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
 	return &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
 		DialContext: (&net.Dialer{
@@ -57,8 +109,6 @@ func createInsecureTransport() *http.Transport {
 		TLSHandshakeTimeout:   15 * time.Second,
 		ResponseHeaderTimeout: 15 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: true,
-		},
-	}
+		TLSClientConfig:       tlsConfig,
+	}, nil
 }
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"net"
+	"time"
+
+	"github.com/go-acme/lego/v3/challenge"
+	"github.com/go-acme/lego/v3/challenge/dns01"
+	"github.com/miekg/dns"
+
+	"github.com/alphatr/acme-lego/common/config"
+)
+
+// systemNameservers 系统的 DNS 服务器, 与 lego 的默认值一致
+var systemNameservers = getSystemNameservers()
+
+func getSystemNameservers() []string {
+	conf, err := dns.ClientConfigFromFile("/etc/resolv.conf")
+	if err != nil || len(conf.Servers) == 0 {
+		return []string{"google-public-dns-a.google.com:53", "google-public-dns-b.google.com:53"}
+	}
+
+	servers := []string{}
+	for _, server := range conf.Servers {
+		servers = append(servers, net.JoinHostPort(server, conf.Port))
+	}
+
+	return servers
+}
+
+// dnsOptions 返回 DNS 验证的参数, 递归 DNS 服务器是 lego 的全局设置, 每个域名都需要重新设置
+func dnsOptions(conf config.DNSConf) []dns01.ChallengeOption {
+	nameservers := systemNameservers
+	if len(conf.Resolvers) > 0 {
+		nameservers = conf.Resolvers
+	}
+
+	return []dns01.ChallengeOption{
+		dns01.AddRecursiveNameservers(nameservers),
+		dns01.CondOption(conf.DisableCP, dns01.DisableCompletePropagationRequirement()),
+	}
+}
+
+// dnsTimeoutProvider 覆盖 Provider 的传播检查超时时间和间隔
+type dnsTimeoutProvider struct {
+	challenge.Provider
+	timeout  time.Duration
+	interval time.Duration
+}
+
+// Timeout 返回传播检查超时时间和间隔
+func (p *dnsTimeoutProvider) Timeout() (time.Duration, time.Duration) {
+	timeout, interval := dns01.DefaultPropagationTimeout, dns01.DefaultPollingInterval
+	if provider, ok := p.Provider.(challenge.ProviderTimeout); ok {
+		timeout, interval = provider.Timeout()
+	}
+
+	if p.timeout > 0 {
+		timeout = p.timeout
+	}
+
+	if p.interval > 0 {
+		interval = p.interval
+	}
+
+	return timeout, interval
+}
+
+// dnsSequentialProvider 保留 Provider 的顺序执行设置
+type dnsSequentialProvider struct {
+	*dnsTimeoutProvider
+	sequential interface {
+		Sequential() time.Duration
+	}
+}
+
+// Sequential 返回顺序执行的间隔
+func (p *dnsSequentialProvider) Sequential() time.Duration {
+	return p.sequential.Sequential()
+}
+
+// wrapDNSProvider 应用配置的传播检查超时时间和间隔
+func wrapDNSProvider(provider challenge.Provider, conf config.DNSConf) challenge.Provider {
+	if conf.PropagationTimeout == 0 && conf.PollingInterval == 0 {
+		return provider
+	}
+
+	wrapped := &dnsTimeoutProvider{Provider: provider, timeout: conf.PropagationTimeout, interval: conf.PollingInterval}
+	if sequential, ok := provider.(interface{ Sequential() time.Duration }); ok {
+		return &dnsSequentialProvider{dnsTimeoutProvider: wrapped, sequential: sequential}
+	}
+
+	return wrapped
+}
@@ -6,9 +6,28 @@ import (
 	"github.com/alphatr/acme-lego/common/errors"
 )
 
-// AccountRegister 注册账户
-func (cli *Client) AccountRegister() (*registration.Resource, *errors.Error) {
-	reg, errs := cli.lego.Registration.Register(registration.RegisterOptions{TermsOfServiceAgreed: true})
+// AccountRegister 注册账户, eabKid 不为空时使用 External Account Binding 注册
+func (cli *Client) AccountRegister(eabKid string, eabHmacKey string) (*registration.Resource, *errors.Error) {
+	if len(eabKid) == 0 {
+		if cli.lego.GetExternalAccountRequired() {
+			return nil, errors.NewError(errors.ModelClientEABRequiredErrno, nil)
+		}
+
+		reg, errs := cli.lego.Registration.Register(registration.RegisterOptions{TermsOfServiceAgreed: true})
+		if errs != nil {
+			return nil, errors.NewError(errors.ModelClientRegisterErrno, errs)
+		}
+
+		return reg, nil
+	}
+
+	options := registration.RegisterEABOptions{
+		TermsOfServiceAgreed: true,
+		Kid:                  eabKid,
+		HmacEncoded:          eabHmacKey,
+	}
+
+	reg, errs := cli.lego.Registration.RegisterWithExternalAccountBinding(options)
 	if errs != nil {
 		return nil, errors.NewError(errors.ModelClientRegisterErrno, errs)
 	}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"strings"
+
+	"github.com/go-acme/lego/v3/acme"
+	"github.com/go-acme/lego/v3/acme/api"
+	"github.com/go-acme/lego/v3/challenge"
+	legoresolver "github.com/go-acme/lego/v3/challenge/resolver"
+	"github.com/go-acme/lego/v3/log"
+
+	"github.com/alphatr/acme-lego/common/config"
+	"github.com/alphatr/acme-lego/common/errors"
+	chall "github.com/alphatr/acme-lego/model/challenge"
+)
+
+// solverEntry 单个域名使用的验证方式
+type solverEntry struct {
+	Type     chall.ProviderType
+	Provider challenge.Provider
+}
+
+// solveError 各组授权的验证错误
+type solveError []error
+
+func (err solveError) Error() string {
+	output := []string{}
+	for _, item := range err {
+		output = append(output, strings.TrimSpace(item.Error()))
+	}
+
+	return strings.Join(output, "; ")
+}
+
+// resolver 按域名选择验证方式, lego 的 SolverManager 每种验证类型只能设置一个 Provider.
+// 只替代 lego v3.9.0 challenge/resolver/solver_manager.go 中 chooseSolver 的选择, 按域名把授权分组,
+// 每组的验证、等待和清理仍然由 lego 的 SolverManager 和 Prober (challenge/resolver/prober.go) 完成
+type resolver struct {
+	core    *api.Core
+	solvers map[string]*solverEntry
+	dns     config.DNSConf
+}
+
+func newResolver(core *api.Core) *resolver {
+	return &resolver{core: core, solvers: map[string]*solverEntry{}}
+}
+
+// Solve 按每个授权配置的验证方式分组, 依次交给 lego 完成验证
+func (res *resolver) Solve(authorizations []acme.Authorization) error {
+	failures := solveError{}
+
+	entries := []*solverEntry{}
+	groups := map[*solverEntry][]acme.Authorization{}
+
+	for _, authz := range authorizations {
+		if authz.Status == acme.StatusValid {
+			log.Infof("[%s] acme: authorization already valid; skipping challenge", challenge.GetTargetedDomain(authz))
+			continue
+		}
+
+		entry, err := res.chooseEntry(authz)
+		if err != nil {
+			failures = append(failures, err)
+			continue
+		}
+
+		if _, ok := groups[entry]; !ok {
+			entries = append(entries, entry)
+		}
+
+		groups[entry] = append(groups[entry], authz)
+	}
+
+	for _, entry := range entries {
+		if err := res.solve(entry, groups[entry]); err != nil {
+			failures = append(failures, err)
+		}
+	}
+
+	if len(failures) > 0 {
+		return failures
+	}
+
+	return nil
+}
+
+// chooseEntry 返回域名配置的验证方式, CA 没有提供对应的验证类型时返回错误
+func (res *resolver) chooseEntry(authz acme.Authorization) (*solverEntry, *errors.Error) {
+	domain := challenge.GetTargetedDomain(authz)
+
+	entry, ok := res.solvers[strings.ToLower(domain)]
+	if !ok {
+		return nil, errors.NewError(errors.ModelClientNoSolverErrno, nil, domain)
+	}
+
+	chlgType := map[chall.ProviderType]challenge.Type{
+		chall.ProviderHTTP: challenge.HTTP01,
+		chall.ProviderTLS:  challenge.TLSALPN01,
+		chall.ProviderDNS:  challenge.DNS01,
+	}[entry.Type]
+
+	if _, err := challenge.FindChallenge(chlgType, authz); err != nil {
+		return nil, errors.NewError(errors.ModelClientChallengeOfferErrno, err, domain, chlgType)
+	}
+
+	return entry, nil
+}
+
+// solve 只设置这一组的 Provider, lego 只会选择这一种验证类型
+func (res *resolver) solve(entry *solverEntry, authorizations []acme.Authorization) error {
+	manager := legoresolver.NewSolversManager(res.core)
+
+	var err error
+	switch entry.Type {
+	case chall.ProviderHTTP:
+		err = manager.SetHTTP01Provider(entry.Provider)
+	case chall.ProviderTLS:
+		err = manager.SetTLSALPN01Provider(entry.Provider)
+	default:
+		err = manager.SetDNS01Provider(wrapDNSProvider(entry.Provider, res.dns), dnsOptions(res.dns)...)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return legoresolver.NewProber(manager).Solve(authorizations)
+}